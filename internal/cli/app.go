@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/history"
+	"qiniu-uploader/internal/token"
+	"qiniu-uploader/pkg/imaging"
 	"qiniu-uploader/pkg/qiniu"
 )
 
@@ -14,39 +18,178 @@ import (
 type App struct {
 	rootCmd *cobra.Command
 	client  *qiniu.Client
-	config  *config.Config
+	// clientReady 客户端已按当前配置创建，配置变化后由 resetClient 清除
+	clientReady     bool
+	config          *config.Config
 	dragDropHandler *DragDropHandler
+
+	// uploadOpts 当前上传命令的选项
+	uploadOpts uploadOptions
+
+	// profile 命令行 --profile 指定的profile
+	profile string
+	// configFile 命令行 --config 指定的配置文件
+	configFile string
+
+	// linkFormat 上传成功后输出的链接格式，交互模式中可通过 format 命令切换
+	linkFormat string
+
+	// history 本机上传历史，配置目录不可用时为nil
+	history *history.Store
+	// tokens HTTP服务的API令牌，配置目录不可用时为nil
+	tokens *token.Store
 }
 
-// NewApp 创建新的命令行应用
+// uploadOptions 上传命令选项
+type uploadOptions struct {
+	key          string
+	refreshCDN   bool
+	resize       string
+	quality      int
+	optimizePNG  bool
+	keepMetadata bool
+	convert      string
+	// alwaysCopy 忽略 auto_copy_url 总是复制链接（qu paste）
+	alwaysCopy bool
+}
+
+// NewApp 创建新的命令行应用，配置在解析命令行参数后加载
 func NewApp() *App {
 	app := &App{}
 
+	app.setupCommands()
+
+	// 初始化拖拽处理器
+	app.dragDropHandler = NewDragDropHandler(app)
+
+	return app
+}
+
+// loadConfig 加载配置并应用默认profile，七牛云客户端在首次使用时创建
+func (a *App) loadConfig() {
+	if a.configFile != "" {
+		config.SetFile(a.configFile)
+	}
+
 	// 加载配置
 	cfg, err := config.Load()
 	if err != nil {
 		fmt.Printf("警告: 加载配置失败: %v\n", err)
 		fmt.Println("请运行 'qu config init' 初始化配置")
 	}
-	app.config = cfg
+	a.config = cfg
+	if cfg != nil {
+		a.linkFormat = cfg.LinkFormat
 
-	// 初始化七牛云客户端
-	if cfg != nil && cfg.QiniuAccessKey != "" && cfg.QiniuSecretKey != "" && cfg.QiniuBucket != "" {
-		qiniuConfig := &qiniu.Config{
-			AccessKey: cfg.QiniuAccessKey,
-			SecretKey: cfg.QiniuSecretKey,
-			Bucket:    cfg.QiniuBucket,
-			Domain:    cfg.QiniuDomain,
+		// 应用默认profile，失败时回退到顶层配置
+		if err := cfg.UseProfile(cfg.StartupProfile()); err != nil {
+			fmt.Printf("警告: %v，使用默认配置\n", err)
 		}
-		app.client = qiniu.NewClient(qiniuConfig)
 	}
 
-	app.setupCommands()
+	// 打开本机上传历史和API令牌
+	if dir, err := config.Dir(); err == nil {
+		a.history = history.Open(dir)
+		a.tokens = token.Open(dir)
+	}
+}
 
-	// 初始化拖拽处理器
-	app.dragDropHandler = NewDragDropHandler(app)
+// resetClient 配置变化后丢弃已创建的七牛云客户端，下次使用时重新创建
+func (a *App) resetClient() {
+	a.client = nil
+	a.clientReady = false
+}
 
-	return app
+// qiniuClient 返回七牛云客户端，首次使用时解析密钥并创建，配置不完整时为nil。
+// 只有需要连接七牛云的命令才会读取密钥环、加密文件或执行 cmd: 引用
+func (a *App) qiniuClient() *qiniu.Client {
+	if a.clientReady {
+		return a.client
+	}
+	a.clientReady = true
+
+	cfg := a.config
+	if cfg == nil {
+		return nil
+	}
+	_ = cfg.ResolveSecrets()
+	accessKey, secretKey := cfg.CredentialSources()
+	for _, info := range []config.SecretInfo{accessKey, secretKey} {
+		if info.Err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 读取密钥失败 (%s): %v\n", info.Source, info.Err)
+		}
+	}
+	if cfg.QiniuAccessKey != "" && cfg.QiniuSecretKey != "" && cfg.QiniuBucket != "" {
+		a.client = qiniu.NewClient(newQiniuConfig(cfg))
+	}
+	return a.client
+}
+
+// switchProfile 切换当前会话使用的profile
+func (a *App) switchProfile(name string) error {
+	if a.config == nil {
+		return fmt.Errorf("配置未加载，请先运行 'qu config init' 初始化配置")
+	}
+	if err := a.config.UseProfile(name); err != nil {
+		return err
+	}
+	a.resetClient()
+	return nil
+}
+
+// newQiniuConfig 根据应用配置生成七牛云客户端配置
+func newQiniuConfig(cfg *config.Config) *qiniu.Config {
+	return &qiniu.Config{
+		AccessKey:      cfg.QiniuAccessKey,
+		SecretKey:      cfg.QiniuSecretKey,
+		Bucket:         cfg.QiniuBucket,
+		Domain:         cfg.QiniuDomain,
+		KeyPrefix:      cfg.KeyPrefix,
+		KeyTemplate:    cfg.KeyTemplate,
+		StyleSeparator: cfg.StyleSeparator,
+		StripMetadata:  cfg.StripMetadata,
+	}
+}
+
+// imageOptions 合并配置和命令行参数，生成上传前的图片预处理选项
+func (a *App) imageOptions() (imaging.Options, error) {
+	var opts imaging.Options
+	if a.config != nil {
+		opts = a.config.ImageOptions()
+	}
+
+	if a.uploadOpts.resize != "" {
+		width, height, err := qiniu.ParseImageSize(a.uploadOpts.resize)
+		if err != nil {
+			return opts, err
+		}
+		opts.MaxWidth = width
+		opts.MaxHeight = height
+	}
+	if a.uploadOpts.quality != 0 {
+		if a.uploadOpts.quality < 1 || a.uploadOpts.quality > 100 {
+			return opts, fmt.Errorf("质量参数必须在 1-100 之间")
+		}
+		opts.Quality = a.uploadOpts.quality
+	}
+	if a.uploadOpts.optimizePNG {
+		opts.OptimizePNG = true
+	}
+	if a.uploadOpts.keepMetadata {
+		opts.StripMetadata = false
+	}
+	if a.uploadOpts.convert != "" {
+		opts.Convert = a.uploadOpts.convert
+	}
+	convert, err := imaging.ParseConvertFormat(opts.Convert)
+	if err != nil {
+		return opts, err
+	}
+	opts.Convert = convert
+	// 仅命令行显式指定 --convert 时缺少编码器才报错，auto_convert 保留原格式
+	opts.RequireEncoder = a.uploadOpts.convert != ""
+
+	return opts, nil
 }
 
 // setupCommands 设置命令
@@ -62,17 +205,52 @@ func (a *App) setupCommands() {
 			}
 			return nil
 		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			a.loadConfig()
+
+			// config init 可以创建新的profile，由 initConfig 处理
+			if a.profile == "" || cmd.Name() == "init" {
+				return nil
+			}
+			return a.switchProfile(a.profile)
+		},
 	}
+	a.rootCmd.PersistentFlags().StringVar(&a.profile, "profile", "", "使用指定的profile（账号/存储空间配置）")
+	a.rootCmd.PersistentFlags().StringVar(&a.configFile, "config", "", "使用指定的配置文件（默认 ~/.config/qu/config.yaml）")
 
 	// 添加上传命令
 	a.rootCmd.AddCommand(a.newUploadCommand())
 
+	// 添加剪贴板图片上传命令
+	a.rootCmd.AddCommand(a.newPasteCommand())
+
+	// 添加上传历史命令
+	a.rootCmd.AddCommand(a.newHistoryCommand())
+
+	// 添加撤销上传命令
+	a.rootCmd.AddCommand(a.newUndoCommand())
+
 	// 添加服务命令
 	a.rootCmd.AddCommand(a.newServiceCommand())
 
+	// 添加HTTP服务命令
+	a.rootCmd.AddCommand(a.newServeCommand())
+
+	// 添加API令牌命令
+	a.rootCmd.AddCommand(a.newTokenCommand())
+
 	// 添加配置命令
 	a.rootCmd.AddCommand(a.newConfigCommand())
 
+	// 添加环境检查命令
+	a.rootCmd.AddCommand(a.newDoctorCommand())
+
+	// 添加CDN命令
+	a.rootCmd.AddCommand(a.newCDNCommand())
+
+	// 添加图片处理URL命令
+	a.rootCmd.AddCommand(a.newURLCommand())
+
 	// 添加版本命令
 	a.rootCmd.AddCommand(a.newVersionCommand())
 }
@@ -85,12 +263,26 @@ func (a *App) Run() error {
 // newUploadCommand 创建上传命令
 func (a *App) newUploadCommand() *cobra.Command {
 	var filePath string
+	var linkFormat string
 
 	cmd := &cobra.Command{
 		Use:   "upload",
 		Short: "上传文件到七牛云",
 		Long:  "支持交互式上传、拖拽上传和指定文件路径上传",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.uploadOpts.key != "" && filePath == "" && len(args) == 0 {
+				return fmt.Errorf("--key 仅支持上传单个指定文件")
+			}
+			if _, err := a.imageOptions(); err != nil {
+				return err
+			}
+			if linkFormat != "" {
+				if _, err := NewLinkFormatter(linkFormat); err != nil {
+					return err
+				}
+				a.linkFormat = linkFormat
+			}
+
 			if filePath != "" {
 				// 指定文件路径上传
 				return a.uploadFile(filePath)
@@ -107,6 +299,14 @@ func (a *App) newUploadCommand() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&filePath, "file", "f", "", "指定要上传的文件路径")
+	cmd.Flags().StringVar(&linkFormat, "format", "", "链接输出格式: url|markdown|html|bbcode|json 或Go模板，如 '{{.URL}}?v={{.Size}}'")
+	cmd.Flags().StringVarP(&a.uploadOpts.key, "key", "k", "", "指定存储key（已存在时覆盖）")
+	cmd.Flags().BoolVar(&a.uploadOpts.refreshCDN, "refresh-cdn", false, "覆盖已有文件时自动刷新CDN缓存")
+	cmd.Flags().StringVar(&a.uploadOpts.resize, "resize", "", "上传前等比缩小到最大尺寸，如 1920x、x1080、1920x1080")
+	cmd.Flags().IntVar(&a.uploadOpts.quality, "quality", 0, "上传前重新压缩JPEG的质量 (1-100)")
+	cmd.Flags().BoolVar(&a.uploadOpts.optimizePNG, "optimize-png", false, "上传前以最高压缩级别重新编码PNG")
+	cmd.Flags().BoolVar(&a.uploadOpts.keepMetadata, "keep-metadata", false, "保留EXIF/XMP/IPTC等元数据")
+	cmd.Flags().StringVar(&a.uploadOpts.convert, "convert", "", "上传前将JPEG/PNG转换为指定格式 (webp、avif、none)")
 
 	return cmd
 }
@@ -131,22 +331,40 @@ func (a *App) newConfigCommand() *cobra.Command {
 		Long:  "管理七牛云上传工具的配置",
 	}
 
+	cmd.AddCommand(a.newConfigInitCommand())
+
 	cmd.AddCommand(&cobra.Command{
-		Use:   "init",
-		Short: "初始化配置",
+		Use:   "show",
+		Short: "显示当前配置",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return a.initConfig()
+			return a.showConfig()
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   "show",
-		Short: "显示当前配置",
+		Use:   "use <profile>",
+		Short: "设置默认使用的profile",
+		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return a.showConfig()
+			return a.useProfile(args[0])
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "列出全部profile",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.listProfiles()
 		},
 	})
 
+	cmd.AddCommand(a.newConfigSetCommand())
+	cmd.AddCommand(a.newConfigGetCommand())
+	cmd.AddCommand(a.newConfigUnsetCommand())
+	cmd.AddCommand(a.newConfigEditCommand())
+	cmd.AddCommand(a.newConfigValidateCommand())
+
 	return cmd
 }
 
@@ -163,7 +381,17 @@ func (a *App) newVersionCommand() *cobra.Command {
 
 // uploadFile 上传单个文件
 func (a *App) uploadFile(filePath string) error {
-	if a.client == nil {
+	source, err := filepath.Abs(filePath)
+	if err != nil {
+		source = filePath
+	}
+	return a.uploadFileAs(filePath, source)
+}
+
+// uploadFileAs 上传单个文件，source 为写入上传历史的来源（本地路径或剪贴板）
+func (a *App) uploadFileAs(filePath, source string) error {
+	client := a.qiniuClient()
+	if client == nil {
 		return fmt.Errorf("七牛云客户端未初始化，请先运行 'qu config init' 配置七牛云信息")
 	}
 
@@ -172,22 +400,85 @@ func (a *App) uploadFile(filePath string) error {
 		return fmt.Errorf("文件不存在: %s", filePath)
 	}
 
-	fmt.Printf("正在上传: %s\n", filepath.Base(filePath))
+	preprocess, err := a.imageOptions()
+	if err != nil {
+		return err
+	}
 
-	result, err := a.client.UploadFile(filePath)
+	formatter, err := NewLinkFormatter(a.linkFormat)
+	if err != nil {
+		return err
+	}
+
+	// 过程信息输出到stderr，stdout只输出格式化后的链接，便于管道和粘贴
+	log := os.Stderr
+	fmt.Fprintf(log, "正在上传: %s\n", filepath.Base(filePath))
+
+	result, err := client.UploadFileWithOptions(filePath, &qiniu.UploadOptions{
+		Key:          a.uploadOpts.key,
+		Preprocess:   preprocess,
+		KeepMetadata: a.uploadOpts.keepMetadata,
+	})
 	if err != nil {
 		return fmt.Errorf("上传失败: %v", err)
 	}
 
-	if result.Success {
-		fmt.Printf("✅ 上传成功!\n")
-		fmt.Printf("📁 文件名: %s\n", filepath.Base(filePath))
-		fmt.Printf("📊 文件大小: %.2f MB\n", float64(result.FileSize)/1024/1024)
-		fmt.Printf("🔗 访问链接: %s\n", result.FileURL)
-		fmt.Printf("🔑 存储Key: %s\n", result.Key)
+	if !result.Success {
+		fmt.Fprintf(log, "❌ 上传失败: %s\n", result.Message)
+		return nil
+	}
+
+	fmt.Fprintf(log, "✅ 上传成功!\n")
+	a.recordHistory(source, result)
+	fmt.Fprintf(log, "📁 文件名: %s\n", filepath.Base(filePath))
+	if result.Processed {
+		fmt.Fprintf(log, "📊 文件大小: %.2f MB → %.2f MB (预处理后)\n",
+			float64(result.OriginalSize)/1024/1024,
+			float64(result.FileSize)/1024/1024)
 	} else {
-		fmt.Printf("❌ 上传失败: %s\n", result.Message)
+		fmt.Fprintf(log, "📊 文件大小: %.2f MB\n", float64(result.FileSize)/1024/1024)
+	}
+	fmt.Fprintf(log, "🔑 存储Key: %s\n", result.Key)
+
+	if result.Format != result.OriginalFormat {
+		fmt.Fprintf(log, "🔄 格式转换: %s → %s\n", result.OriginalFormat, result.Format)
+	} else if result.ConvertSkipReason == imaging.ConvertSkipLarger {
+		fmt.Fprintln(log, "💡 转换后体积更大，已保留原格式")
+	} else if result.ConvertSkipped {
+		fmt.Fprintf(log, "⚠️  %s，已保留原格式\n", result.ConvertSkipReason)
+	}
+	if result.GPSRemoved {
+		fmt.Fprintln(log, "⚠️  检测到GPS位置信息，已在上传前移除")
+	}
+	if len(result.MetadataRemoved) > 0 {
+		fmt.Fprintf(log, "🧹 已移除元数据: %s\n", strings.Join(result.MetadataRemoved, ", "))
 	}
 
+	if result.Overwritten {
+		fmt.Fprintln(log, "♻️  已覆盖云端同名文件")
+		if a.uploadOpts.refreshCDN {
+			if err := a.refreshCDN(log, []string{result.FileURL}, nil); err != nil {
+				fmt.Fprintf(log, "⚠️  刷新CDN失败: %v\n", err)
+			}
+		} else {
+			fmt.Fprintln(log, "💡 提示: CDN可能仍缓存旧文件，可使用 --refresh-cdn 或 'qu cdn refresh' 刷新")
+		}
+	}
+
+	link, err := formatter.Format(LinkData{
+		Key:      result.Key,
+		URL:      result.FileURL,
+		Size:     result.FileSize,
+		MimeType: result.MimeType,
+		Width:    result.Width,
+		Height:   result.Height,
+		Alt:      altText(filePath),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(link)
+	a.copyToClipboard(link)
+
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"qiniu-uploader/pkg/qiniu"
+)
+
+// newCDNCommand 创建CDN命令
+func (a *App) newCDNCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cdn",
+		Short: "CDN缓存管理",
+		Long:  "刷新或预取CDN缓存，参数可以是存储key或完整URL",
+	}
+
+	var dirs []string
+	refreshCmd := &cobra.Command{
+		Use:   "refresh [url|key]...",
+		Short: "刷新CDN缓存",
+		Long:  "刷新文件缓存，以 / 结尾的参数或 --dir 指定的路径按目录刷新",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.qiniuClient() == nil {
+				return fmt.Errorf("七牛云客户端未初始化，请先运行 'qu config init' 配置七牛云信息")
+			}
+
+			var files []string
+			for _, arg := range args {
+				if strings.HasSuffix(arg, "/") {
+					dirs = append(dirs, arg)
+				} else {
+					files = append(files, arg)
+				}
+			}
+			if len(files) == 0 && len(dirs) == 0 {
+				return fmt.Errorf("请指定需要刷新的文件或目录")
+			}
+
+			return a.refreshCDN(os.Stdout, files, dirs)
+		},
+	}
+	refreshCmd.Flags().StringSliceVarP(&dirs, "dir", "d", nil, "需要刷新的目录（可重复指定）")
+	cmd.AddCommand(refreshCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "prefetch <url|key>...",
+		Short: "预取文件到CDN",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := a.qiniuClient()
+			if client == nil {
+				return fmt.Errorf("七牛云客户端未初始化，请先运行 'qu config init' 配置七牛云信息")
+			}
+
+			result, err := client.CDN().Prefetch(args)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("✅ 预取请求已提交")
+			printRefreshResult(os.Stdout, result)
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// refreshCDN 刷新CDN缓存并将结果输出到 w，失败时由调用方输出错误
+func (a *App) refreshCDN(w io.Writer, files, dirs []string) error {
+	result, err := a.qiniuClient().CDN().Refresh(files, dirs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "🔄 CDN刷新请求已提交")
+	printRefreshResult(w, result)
+	return nil
+}
+
+// printRefreshResult 将刷新/预取结果及配额使用情况输出到 w
+func printRefreshResult(w io.Writer, result *qiniu.RefreshResult) {
+	for _, url := range result.URLs {
+		fmt.Fprintf(w, "   文件: %s\n", url)
+	}
+	for _, dir := range result.Dirs {
+		fmt.Fprintf(w, "   目录: %s\n", dir)
+	}
+	for _, url := range append(result.InvalidURLs, result.InvalidDirs...) {
+		fmt.Fprintf(w, "⚠️  无效链接: %s\n", url)
+	}
+
+	if result.URLQuotaDay > 0 {
+		fmt.Fprintf(w, "📊 今日文件配额: 剩余 %d / %d\n", result.URLSurplusDay, result.URLQuotaDay)
+	}
+	if result.DirQuotaDay > 0 {
+		fmt.Fprintf(w, "📊 今日目录配额: 剩余 %d / %d\n", result.DirSurplusDay, result.DirQuotaDay)
+	}
+	if result.RequestID != "" {
+		fmt.Fprintf(w, "🆔 请求ID: %s\n", result.RequestID)
+	}
+}
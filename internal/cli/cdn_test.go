@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"qiniu-uploader/pkg/qiniu"
+)
+
+func TestPrintRefreshResult(t *testing.T) {
+	var buf bytes.Buffer
+	printRefreshResult(&buf, &qiniu.RefreshResult{
+		RequestID:     "req-1",
+		URLs:          []string{"https://cdn.example.com/a.png"},
+		URLQuotaDay:   100,
+		URLSurplusDay: 99,
+	})
+
+	out := buf.String()
+	for _, want := range []string{"https://cdn.example.com/a.png", "99 / 100", "req-1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q does not contain %q", out, want)
+		}
+	}
+}
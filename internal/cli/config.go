@@ -0,0 +1,343 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"qiniu-uploader/internal/config"
+)
+
+// stdin 共享的标准输入读取器，多处读取时不会丢失缓冲的数据
+var stdin = bufio.NewReader(os.Stdin)
+
+// readLine 显示提示并读取一行输入，去除首尾空白
+func readLine(prompt string) string {
+	fmt.Print(prompt)
+	line, _ := stdin.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// initOptions 通过参数初始化配置，任意参数被指定时不再交互式输入
+type initOptions struct {
+	accessKey   string
+	secretKey   string
+	bucket      string
+	domain      string
+	keyPrefix   string
+	keyTemplate string
+	secretStore string
+	set         []string
+	skipVerify  bool
+}
+
+// initFlags init 命令中与配置项对应的参数
+var initFlags = map[string]string{
+	"access-key":   "qiniu_access_key",
+	"secret-key":   "qiniu_secret_key",
+	"bucket":       "qiniu_bucket",
+	"domain":       "qiniu_domain",
+	"key-prefix":   "key_prefix",
+	"key-template": "key_template",
+}
+
+// values 返回需要写入的配置项，按参数顺序排列，--set 在最后
+func (o *initOptions) values(cmd *cobra.Command) ([][2]string, error) {
+	flagValues := map[string]string{
+		"access-key":   o.accessKey,
+		"secret-key":   o.secretKey,
+		"bucket":       o.bucket,
+		"domain":       o.domain,
+		"key-prefix":   o.keyPrefix,
+		"key-template": o.keyTemplate,
+	}
+
+	var values [][2]string
+	for _, name := range []string{"access-key", "secret-key", "bucket", "domain", "key-prefix", "key-template"} {
+		if cmd.Flags().Changed(name) {
+			values = append(values, [2]string{initFlags[name], flagValues[name]})
+		}
+	}
+	for _, item := range o.set {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("无效的 --set 参数: %s（格式应为 key=value）", item)
+		}
+		values = append(values, [2]string{strings.TrimSpace(key), value})
+	}
+	return values, nil
+}
+
+// newConfigInitCommand 创建配置初始化命令
+func (a *App) newConfigInitCommand() *cobra.Command {
+	var opts initOptions
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "初始化配置",
+		Long: `初始化配置，不带参数时交互式输入。
+指定任意参数时不再询问，适用于脚本和CI，例如:
+  qu config init --access-key env:QINIU_AK --secret-key env:QINIU_SK --bucket my-bucket --set link_format=markdown`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			values, err := opts.values(cmd)
+			if err != nil {
+				return err
+			}
+			if len(values) == 0 && !cmd.Flags().Changed("secret-store") {
+				err = a.initConfig()
+			} else {
+				err = a.initConfigWith(values, opts.secretStore)
+			}
+			if err != nil || opts.skipVerify {
+				return err
+			}
+
+			// 保存后验证密钥、存储空间和域名，未通过时配置仍然保留
+			fmt.Println()
+			if !a.runDoctor() {
+				fmt.Println("\n⚠️  配置已保存，但检查未通过，修复后可运行 'qu doctor' 重新检查")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.accessKey, "access-key", "", "Access Key，可使用 env:VAR 或 cmd:命令 引用")
+	cmd.Flags().StringVar(&opts.secretKey, "secret-key", "", "Secret Key，可使用 env:VAR 或 cmd:命令 引用")
+	cmd.Flags().StringVar(&opts.bucket, "bucket", "", "存储空间名称")
+	cmd.Flags().StringVar(&opts.domain, "domain", "", "访问域名")
+	cmd.Flags().StringVar(&opts.keyPrefix, "key-prefix", "", "自动生成key的前缀")
+	cmd.Flags().StringVar(&opts.keyTemplate, "key-template", "", "自动生成key的模板，如 {prefix}{date}/{name}{ext}")
+	cmd.Flags().StringVar(&opts.secretStore, "secret-store", "", "明文密钥的存储方式: keyring|file|plain")
+	cmd.Flags().StringArrayVar(&opts.set, "set", nil, "设置任意配置项，格式 key=value，可重复")
+	cmd.Flags().BoolVar(&opts.skipVerify, "skip-verify", false, "保存后不检查密钥、存储空间和域名")
+
+	return cmd
+}
+
+// newConfigSetCommand 创建设置配置项命令
+func (a *App) newConfigSetCommand() *cobra.Command {
+	var store string
+
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "设置配置项",
+		Long: `设置配置项并保存，值会按类型和可选值校验。
+使用 --profile 时账号相关的配置写入该profile。
+全部配置项: ` + strings.Join(config.FieldKeys(), ", "),
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.setConfig(args[0], args[1], store)
+		},
+	}
+	cmd.Flags().StringVar(&store, "store", "", "设置密钥时的存储方式: keyring|file|plain")
+
+	return cmd
+}
+
+// newConfigGetCommand 创建读取配置项命令
+func (a *App) newConfigGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "读取配置项",
+		Long:  "输出配置项的值，密钥输出配置文件中保存的引用或明文",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.config == nil {
+				return fmt.Errorf("配置未加载，请先运行 'qu config init' 初始化配置")
+			}
+			value, err := a.config.Get(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Println(value)
+			return nil
+		},
+	}
+}
+
+// newConfigUnsetCommand 创建删除配置项命令
+func (a *App) newConfigUnsetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset <key>",
+		Short: "恢复配置项的默认值",
+		Long:  "恢复配置项的默认值，使用 --profile 时账号相关的配置恢复为继承默认配置",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.config == nil {
+				return fmt.Errorf("配置未加载，请先运行 'qu config init' 初始化配置")
+			}
+			if err := a.config.Unset(args[0]); err != nil {
+				return err
+			}
+			return a.saveConfig()
+		},
+	}
+}
+
+// newConfigEditCommand 创建编辑配置文件命令
+func (a *App) newConfigEditCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "使用编辑器打开配置文件",
+		Long:  "使用 $VISUAL 或 $EDITOR 打开配置文件，保存后自动校验",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.editConfig()
+		},
+	}
+}
+
+// newConfigValidateCommand 创建校验配置文件命令
+func (a *App) newConfigValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [file]",
+		Short: "校验配置文件",
+		Long: `检查配置文件中的未知配置项、类型错误和无效的取值，存在错误时返回非零状态码。
+未指定文件时同时校验当前目录或上级目录中的 ` + config.ProjectFileName,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				return validateConfigFile(args[0])
+			}
+
+			path, err := config.FilePath()
+			if err != nil {
+				return err
+			}
+			err = validateConfigFile(path)
+			if wd, wdErr := os.Getwd(); wdErr == nil {
+				if project := config.FindProjectFile(wd); project != "" {
+					if projectErr := validateConfigFile(project); err == nil {
+						err = projectErr
+					}
+				}
+			}
+			return err
+		},
+	}
+}
+
+// setConfig 设置配置项并保存，明文密钥按 store 指定的方式保存
+func (a *App) setConfig(key, value, store string) error {
+	if a.config == nil {
+		return fmt.Errorf("配置未加载，请先运行 'qu config init' 初始化配置")
+	}
+	f, ok := config.LookupField(key)
+	if !ok {
+		return a.config.Set(key, value)
+	}
+	if store != "" && !f.Secret {
+		return fmt.Errorf("--store 仅适用于 qiniu_access_key 和 qiniu_secret_key")
+	}
+	if f.Key == "current_profile" && value != "" {
+		if err := checkProfileExists(a.config, value); err != nil {
+			return err
+		}
+	}
+
+	if err := a.config.Set(f.Key, value); err != nil {
+		return err
+	}
+	if f.Secret {
+		if err := storeCredentials(a.config, store, []string{f.Key}, true); err != nil {
+			return err
+		}
+	}
+	if a.config.ProjectOverrides(f.Key) {
+		fmt.Printf("⚠️  %s 被项目配置 %s 覆盖，修改仅写入用户配置\n", f.Key, a.config.ProjectFile())
+	}
+	return a.saveConfig()
+}
+
+// checkProfileExists 检查profile是否存在
+func checkProfileExists(cfg *config.Config, name string) error {
+	for _, existing := range cfg.ProfileNames() {
+		if existing == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("profile不存在: %s（可用: %s）", name, strings.Join(cfg.ProfileNames(), ", "))
+}
+
+// saveConfig 保存当前配置并重新创建客户端
+func (a *App) saveConfig() error {
+	if err := config.Save(a.config); err != nil {
+		return fmt.Errorf("保存配置失败: %v", err)
+	}
+	a.resetClient()
+	return nil
+}
+
+// editConfig 使用编辑器打开配置文件，退出后校验
+func (a *App) editConfig() error {
+	path, err := config.FilePath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) && a.config != nil {
+		// 配置文件不存在时先写入当前配置，方便在此基础上修改
+		if err := config.Save(a.config); err != nil {
+			return fmt.Errorf("创建配置文件失败: %v", err)
+		}
+	}
+
+	editor := findEditor()
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("启动编辑器失败 (%s): %v", editor, err)
+	}
+
+	return validateConfigFile(path)
+}
+
+// findEditor 查找编辑器，优先使用 $VISUAL 和 $EDITOR
+func findEditor() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(env)); editor != "" {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// validateConfigFile 校验配置文件并输出问题，存在错误时返回error
+func validateConfigFile(path string) error {
+	validate := config.ValidateFile
+	if filepath.Base(path) == config.ProjectFileName {
+		validate = config.ValidateProjectFile
+	}
+	issues, err := validate(path)
+	if err != nil {
+		return err
+	}
+
+	errors := 0
+	for _, issue := range issues {
+		if issue.Warning {
+			fmt.Printf("⚠️  %s: %s\n", issue.Key, issue.Message)
+			continue
+		}
+		errors++
+		fmt.Printf("❌ %s: %s\n", issue.Key, issue.Message)
+	}
+	if errors > 0 {
+		return fmt.Errorf("配置文件 %s 存在 %d 个错误", path, errors)
+	}
+
+	fmt.Printf("✅ 配置文件有效: %s\n", path)
+	return nil
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"qiniu-uploader/internal/doctor"
+)
+
+// newDoctorCommand 创建环境检查命令
+func (a *App) newDoctorCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "检查密钥、存储空间、域名和本机环境",
+		Long: `检查密钥是否有效、存储空间是否存在且可写入、所在区域、域名是否已绑定并可访问、
+本机时钟偏差以及剪贴板工具，输出检查报告和修复建议。存在失败项时返回非零状态码。
+写入检查会上传一个临时文件并在检查结束后删除。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !a.runDoctor() {
+				return fmt.Errorf("检查未通过")
+			}
+			return nil
+		},
+	}
+}
+
+// runDoctor 执行检查并输出报告，返回是否全部通过
+func (a *App) runDoctor() bool {
+	fmt.Println("🩺 检查七牛云上传工具配置")
+	fmt.Println("=" + strings.Repeat("=", 50))
+	if a.config != nil {
+		fmt.Printf("👤 Profile: %s\n\n", a.config.ProfileName())
+	}
+
+	report := doctor.New(a.config, a.qiniuClient()).Run()
+	report.Print(os.Stdout)
+	return !report.Failed()
+}
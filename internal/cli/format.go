@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// 内置链接格式
+const (
+	LinkFormatURL      = "url"
+	LinkFormatMarkdown = "markdown"
+	LinkFormatHTML     = "html"
+	LinkFormatBBCode   = "bbcode"
+	LinkFormatJSON     = "json"
+)
+
+// builtinLinkFormats 内置链接格式对应的模板
+var builtinLinkFormats = map[string]string{
+	LinkFormatURL:      `{{.URL}}`,
+	LinkFormatMarkdown: `![{{.Alt}}]({{.URL}})`,
+	LinkFormatHTML:     `<img src="{{html .URL}}" alt="{{html .Alt}}"{{if .Width}} width="{{.Width}}" height="{{.Height}}"{{end}}>`,
+	LinkFormatBBCode:   `[img]{{.URL}}[/img]`,
+}
+
+// LinkFormatNames 可选的内置链接格式名称
+var LinkFormatNames = []string{LinkFormatURL, LinkFormatMarkdown, LinkFormatHTML, LinkFormatBBCode, LinkFormatJSON}
+
+// LinkData 链接模板可用的字段
+type LinkData struct {
+	Key      string `json:"key"`
+	URL      string `json:"url"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Alt      string `json:"alt"`
+}
+
+// LinkFormatter 上传结果的链接格式化器
+type LinkFormatter struct {
+	name string
+	tmpl *template.Template
+}
+
+// NewLinkFormatter 创建链接格式化器，format 为内置格式名或Go模板
+func NewLinkFormatter(format string) (*LinkFormatter, error) {
+	name := strings.TrimSpace(format)
+	if name == "" {
+		name = LinkFormatURL
+	}
+
+	lower := strings.ToLower(name)
+	if lower == LinkFormatJSON {
+		return &LinkFormatter{name: LinkFormatJSON}, nil
+	}
+
+	text, ok := builtinLinkFormats[lower]
+	if ok {
+		name = lower
+	} else {
+		if !strings.Contains(name, "{{") {
+			return nil, fmt.Errorf("未知的链接格式: %s（可选 %s 或Go模板）", name, strings.Join(LinkFormatNames, "|"))
+		}
+		text = name
+	}
+
+	tmpl, err := template.New("link").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("链接模板解析失败: %v", err)
+	}
+	return &LinkFormatter{name: name, tmpl: tmpl}, nil
+}
+
+// Name 格式名称，自定义模板时为模板内容
+func (f *LinkFormatter) Name() string {
+	return f.name
+}
+
+// Format 格式化链接
+func (f *LinkFormatter) Format(data LinkData) (string, error) {
+	if f.tmpl == nil {
+		out, err := json.Marshal(data)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+
+	var buf bytes.Buffer
+	if err := f.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("链接模板执行失败: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// altText 根据本地文件名生成替代文本
+func altText(filePath string) string {
+	base := filepath.Base(strings.TrimPrefix(filePath, clipboardSource))
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestLinkFormatter(t *testing.T) {
+	data := LinkData{
+		Key:      "images/1.png",
+		URL:      "https://cdn.example.com/images/1.png",
+		Size:     1024,
+		MimeType: "image/png",
+		Width:    640,
+		Height:   480,
+		Alt:      `my "diagram"`,
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{"Default", "", "https://cdn.example.com/images/1.png"},
+		{"URL", "url", "https://cdn.example.com/images/1.png"},
+		{"Markdown", "Markdown", `![my "diagram"](https://cdn.example.com/images/1.png)`},
+		{"HTML", "html", `<img src="https://cdn.example.com/images/1.png" alt="my &#34;diagram&#34;" width="640" height="480">`},
+		{"BBCode", "bbcode", "[img]https://cdn.example.com/images/1.png[/img]"},
+		{"JSON", "json", `{"key":"images/1.png","url":"https://cdn.example.com/images/1.png","size":1024,"mime_type":"image/png","width":640,"height":480,"alt":"my \"diagram\""}`},
+		{"Template", "{{.Key}} {{.MimeType}} {{.Size}}", "images/1.png image/png 1024"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter, err := NewLinkFormatter(tt.format)
+			if err != nil {
+				t.Fatalf("NewLinkFormatter(%q) error: %v", tt.format, err)
+			}
+			result, err := formatter.Format(data)
+			if err != nil {
+				t.Fatalf("Format() error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("got %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLinkFormatterErrors(t *testing.T) {
+	for _, format := range []string{"rst", "{{.URL"} {
+		if _, err := NewLinkFormatter(format); err == nil {
+			t.Errorf("NewLinkFormatter(%q) expected error", format)
+		}
+	}
+
+	formatter, err := NewLinkFormatter("{{.Missing}}")
+	if err != nil {
+		t.Fatalf("NewLinkFormatter() error: %v", err)
+	}
+	if _, err := formatter.Format(LinkData{}); err == nil {
+		t.Error("expected error for unknown template field")
+	}
+}
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"qiniu-uploader/internal/history"
+	"qiniu-uploader/pkg/qiniu"
+)
+
+// newHistoryCommand 创建上传历史命令
+func (a *App) newHistoryCommand() *cobra.Command {
+	var search, since string
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "查看本机上传历史",
+		Long:  "查看本机上传记录（本地路径、存储key、链接等），支持按关键字和时间筛选",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := historyFilter(search, since)
+			if err != nil {
+				return err
+			}
+			return a.showHistory(filter)
+		},
+	}
+	cmd.Flags().StringVarP(&search, "search", "s", "", "按本地路径、存储key或链接筛选")
+	cmd.Flags().StringVar(&since, "since", "", "只显示指定时间范围内的记录，如 7d、12h、2w")
+
+	var linkFormat string
+	copyCmd := &cobra.Command{
+		Use:   "copy <n>",
+		Short: "复制第 n 条记录的链接到剪贴板",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("无效的序号: %s", args[0])
+			}
+			if linkFormat != "" {
+				a.linkFormat = linkFormat
+			}
+			return a.copyHistoryLink(n)
+		},
+	}
+	copyCmd.Flags().StringVar(&linkFormat, "format", "", "链接输出格式: url|markdown|html|bbcode|json 或Go模板")
+	cmd.AddCommand(copyCmd)
+
+	var asJSON bool
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "导出上传历史",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := historyFilter(search, since)
+			if err != nil {
+				return err
+			}
+			return a.exportHistory(filter, asJSON)
+		},
+	}
+	exportCmd.Flags().BoolVar(&asJSON, "json", false, "以JSON格式导出（默认为制表符分隔的文本）")
+	exportCmd.Flags().StringVarP(&search, "search", "s", "", "按本地路径、存储key或链接筛选")
+	exportCmd.Flags().StringVar(&since, "since", "", "只导出指定时间范围内的记录，如 7d、12h、2w")
+	cmd.AddCommand(exportCmd)
+
+	return cmd
+}
+
+// historyFilter 根据命令行参数生成查询条件
+func historyFilter(search, since string) (history.Filter, error) {
+	filter := history.Filter{Search: search}
+	d, err := history.ParseSince(since)
+	if err != nil {
+		return filter, err
+	}
+	if d > 0 {
+		filter.Since = time.Now().Add(-d)
+	}
+	return filter, nil
+}
+
+// historyStore 返回历史记录存储，配置目录不可用时返回错误
+func (a *App) historyStore() (*history.Store, error) {
+	if a.history == nil {
+		return nil, fmt.Errorf("无法访问配置目录，上传历史不可用")
+	}
+	return a.history, nil
+}
+
+// recordHistory 记录一次成功的上传，失败时仅提示
+func (a *App) recordHistory(source string, result *qiniu.UploadResult) {
+	if a.history == nil {
+		return
+	}
+
+	entry := history.Entry{
+		LocalPath:   source,
+		Key:         result.Key,
+		URL:         result.FileURL,
+		Hash:        result.Hash,
+		Size:        result.FileSize,
+		MimeType:    result.MimeType,
+		Width:       result.Width,
+		Height:      result.Height,
+		Overwritten: result.Overwritten,
+	}
+	if a.config != nil {
+		entry.Bucket = a.config.QiniuBucket
+	}
+	if err := a.history.Add(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  记录上传历史失败: %v\n", err)
+	}
+}
+
+// showHistory 显示上传历史
+func (a *App) showHistory(filter history.Filter) error {
+	store, err := a.historyStore()
+	if err != nil {
+		return err
+	}
+	items, err := store.Find(filter)
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		fmt.Println("  暂无上传记录")
+		return nil
+	}
+
+	fmt.Println("\n🕘 上传历史:")
+	fmt.Println("-" + strings.Repeat("-", 80))
+	for i, item := range items {
+		fmt.Printf("%3d. %s\n", item.Index, filepath.Base(item.LocalPath))
+		fmt.Printf("     大小: %.2f MB | 上传时间: %s\n",
+			float64(item.Size)/1024/1024,
+			item.UploadedAt.Local().Format("2006-01-02 15:04:05"))
+		fmt.Printf("     本地: %s\n", item.LocalPath)
+		fmt.Printf("     链接: %s\n", item.URL)
+		if i < len(items)-1 {
+			fmt.Println()
+		}
+	}
+	fmt.Println("\n💡 使用 'qu history copy <序号>' 重新复制链接")
+	return nil
+}
+
+// copyHistoryLink 按当前链接格式输出并复制第 n 条记录的链接
+func (a *App) copyHistoryLink(n int) error {
+	store, err := a.historyStore()
+	if err != nil {
+		return err
+	}
+	entry, err := store.Get(n)
+	if err != nil {
+		return err
+	}
+
+	formatter, err := NewLinkFormatter(a.linkFormat)
+	if err != nil {
+		return err
+	}
+	link, err := formatter.Format(LinkData{
+		Key:      entry.Key,
+		URL:      entry.URL,
+		Size:     entry.Size,
+		MimeType: entry.MimeType,
+		Width:    entry.Width,
+		Height:   entry.Height,
+		Alt:      altText(entry.LocalPath),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(link)
+	if err := CopyToClipboard(a.clipboardCommand(), link); err != nil {
+		return fmt.Errorf("复制到剪贴板失败: %v", err)
+	}
+	fmt.Fprintln(os.Stderr, "📋 已复制到剪贴板")
+	return nil
+}
+
+// exportHistory 导出上传历史到stdout
+func (a *App) exportHistory(filter history.Filter, asJSON bool) error {
+	store, err := a.historyStore()
+	if err != nil {
+		return err
+	}
+	items, err := store.Find(filter)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		entries := make([]history.Entry, 0, len(items))
+		for _, item := range items {
+			entries = append(entries, item.Entry)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	for _, item := range items {
+		fmt.Printf("%d\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			item.Index,
+			item.UploadedAt.Local().Format(time.RFC3339),
+			item.LocalPath,
+			item.Key,
+			item.URL,
+			item.Size,
+			item.Hash)
+	}
+	return nil
+}
@@ -7,13 +7,15 @@ import (
 	"path/filepath"
 	"strings"
 
+	"qiniu-uploader/internal/clipboard"
 	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/secret"
 	"qiniu-uploader/pkg/qiniu"
 )
 
 // startInteractiveUpload 启动交互式上传
 func (a *App) startInteractiveUpload() error {
-	if a.client == nil {
+	if a.qiniuClient() == nil {
 		fmt.Println("❌ 七牛云客户端未初始化")
 		fmt.Println("请先运行 'qu config init' 配置七牛云信息")
 		return fmt.Errorf("七牛云客户端未初始化")
@@ -25,7 +27,11 @@ func (a *App) startInteractiveUpload() error {
 	fmt.Println("  1. 输入文件路径上传 (支持拖拽文件到终端)")
 	fmt.Println("  2. 输入 'list' 查看已上传文件")
 	fmt.Println("  3. 输入 'config' 显示当前配置")
-	fmt.Println("  4. 输入 'quit' 或 'exit' 退出")
+	fmt.Println("  4. 输入 'format [格式]' 查看或切换链接格式")
+	fmt.Println("  5. 输入 'paste' 上传剪贴板中的图片")
+	fmt.Println("  6. 输入 'undo [N]' 删除最近 N 次上传的文件")
+	fmt.Println("  7. 输入 'profile [名称]' 查看或切换profile")
+	fmt.Println("  8. 输入 'quit' 或 'exit' 退出")
 	fmt.Println("=" + strings.Repeat("=", 50))
 
 	// 显示拖拽使用说明
@@ -57,6 +63,7 @@ func (a *App) startInputLoop() error {
 		}
 
 		input := strings.TrimSpace(scanner.Text())
+		command, arg, _ := strings.Cut(input, " ")
 
 		// 处理命令
 		switch strings.ToLower(input) {
@@ -67,7 +74,24 @@ func (a *App) startInputLoop() error {
 			a.listUploadedFiles()
 		case "config":
 			a.showConfig()
+		case "paste":
+			if err := a.uploadClipboardImage(); err != nil {
+				fmt.Printf("❌ 错误: %v\n", err)
+			}
 		default:
+			if strings.ToLower(command) == "format" {
+				a.switchLinkFormat(strings.TrimSpace(arg))
+				continue
+			}
+			if strings.ToLower(command) == "profile" {
+				a.interactiveProfile(strings.TrimSpace(arg))
+				continue
+			}
+			if strings.ToLower(command) == "undo" {
+				a.interactiveUndo(scanner, strings.TrimSpace(arg))
+				continue
+			}
+
 			// 处理文件上传
 			if err := a.handleFileInput(input); err != nil {
 				fmt.Printf("❌ 错误: %v\n", err)
@@ -78,6 +102,51 @@ func (a *App) startInputLoop() error {
 	return scanner.Err()
 }
 
+// interactiveUndo 交互模式中撤销上传，确认输入与主循环共用同一个scanner
+func (a *App) interactiveUndo(scanner *bufio.Scanner, arg string) {
+	n, err := parseUndoCount(strings.Fields(arg))
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	client := a.qiniuClient()
+	if client == nil {
+		fmt.Println("❌ 错误: 七牛云客户端未初始化，请先运行 'qu config init' 配置七牛云信息")
+		return
+	}
+
+	confirm := func(prompt string) bool {
+		fmt.Print(prompt)
+		return scanner.Scan() && isYes(scanner.Text())
+	}
+	if err := a.undoUploads(client, n, confirm, confirm); err != nil {
+		fmt.Printf("❌ 错误: %v\n", err)
+	}
+}
+
+// switchLinkFormat 查看或切换当前会话的链接格式
+func (a *App) switchLinkFormat(format string) {
+	if format == "" {
+		current := a.linkFormat
+		if current == "" {
+			current = LinkFormatURL
+		}
+		fmt.Printf("🔗 当前链接格式: %s\n", current)
+		fmt.Printf("   可选: %s，或Go模板（字段: .Key .URL .Size .MimeType .Width .Height .Alt）\n",
+			strings.Join(LinkFormatNames, ", "))
+		return
+	}
+
+	formatter, err := NewLinkFormatter(format)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	a.linkFormat = formatter.Name()
+	fmt.Printf("✅ 链接格式已切换为: %s\n", a.linkFormat)
+}
+
 // handleFileInput 处理文件输入
 func (a *App) handleFileInput(input string) error {
 	// 如果拖拽处理器可用，使用它来处理文件路径（包括WSL路径转换）
@@ -104,7 +173,8 @@ func (a *App) handleFileInput(input string) error {
 
 // listUploadedFiles 列出已上传文件
 func (a *App) listUploadedFiles() {
-	if a.client == nil {
+	client := a.qiniuClient()
+	if client == nil {
 		fmt.Println("❌ 七牛云客户端未初始化")
 		return
 	}
@@ -112,7 +182,7 @@ func (a *App) listUploadedFiles() {
 	fmt.Println("\n📚 已上传文件列表:")
 	fmt.Println("-" + strings.Repeat("-", 80))
 
-	files, err := a.client.ListFiles("images/", 20)
+	files, err := client.ListFiles(a.config.KeyPrefix, 20)
 	if err != nil {
 		fmt.Printf("❌ 获取文件列表失败: %v\n", err)
 		return
@@ -136,54 +206,125 @@ func (a *App) listUploadedFiles() {
 	fmt.Println("-" + strings.Repeat("-", 80))
 }
 
-// initConfig 初始化配置
+// initConfig 交互式初始化配置
 func (a *App) initConfig() error {
 	fmt.Println("🔧 初始化七牛云上传工具配置")
 	fmt.Println("=" + strings.Repeat("=", 50))
 
-	cfg := &config.Config{}
+	cfg, err := a.newInitConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Profile != "" {
+		fmt.Printf("👤 Profile: %s（未填写的项继承默认配置）\n", cfg.Profile)
+	}
 
-	// 获取七牛云配置
+	// 获取七牛云配置，直接回车保留当前值
 	fmt.Println("\n📋 请输入七牛云配置:")
-	fmt.Print("Access Key: ")
-	fmt.Scanln(&cfg.QiniuAccessKey)
+	fmt.Println("（密钥可输入 env:VAR 或 cmd:命令 引用，如 cmd:pass show qiniu）")
+	prompts := []struct {
+		key    string
+		prompt string
+	}{
+		{"qiniu_access_key", "Access Key: "},
+		{"qiniu_secret_key", "Secret Key: "},
+		{"qiniu_bucket", "Bucket 名称: "},
+		{"qiniu_domain", "域名 (可选): "},
+		{"key_prefix", fmt.Sprintf("Key 前缀 (回车使用 %s): ", cfg.KeyPrefix)},
+	}
+	var entered []string
+	for _, p := range prompts {
+		for {
+			value := readLine(p.prompt)
+			if value == "" {
+				break
+			}
+			if err := cfg.Set(p.key, value); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				continue
+			}
+			entered = append(entered, p.key)
+			break
+		}
+	}
+
+	// 新输入的密钥保存到系统密钥环或加密文件，也可直接输入 env:VAR 或 cmd:命令 引用
+	if err := storeCredentials(cfg, "", entered, true); err != nil {
+		return err
+	}
+
+	return a.finishInitConfig(cfg)
+}
 
-	fmt.Print("Secret Key: ")
-	fmt.Scanln(&cfg.QiniuSecretKey)
+// initConfigWith 使用参数中的配置项初始化配置，不进行交互
+func (a *App) initConfigWith(values [][2]string, secretStore string) error {
+	cfg, err := a.newInitConfig()
+	if err != nil {
+		return err
+	}
 
-	fmt.Print("Bucket 名称: ")
-	fmt.Scanln(&cfg.QiniuBucket)
+	// 只保存参数中显式指定的密钥，不询问存储方式
+	var secrets []string
+	for _, kv := range values {
+		if err := cfg.Set(kv[0], kv[1]); err != nil {
+			return err
+		}
+		if f, ok := config.LookupField(kv[0]); ok && f.Secret {
+			secrets = append(secrets, f.Key)
+		}
+	}
+	if cfg.CurrentProfile != "" {
+		if err := checkProfileExists(cfg, cfg.CurrentProfile); err != nil {
+			return err
+		}
+	}
 
-	fmt.Print("域名 (可选): ")
-	fmt.Scanln(&cfg.QiniuDomain)
+	if err := storeCredentials(cfg, secretStore, secrets, false); err != nil {
+		return err
+	}
 
-	// 设置默认快捷键配置
-	cfg.HotkeyKeys = []int{85} // U键
-	cfg.HotkeyCtrl = true
-	cfg.HotkeyShift = true
-	cfg.HotkeyAlt = false
-	cfg.AutoCopyURL = true
-	cfg.ShowProgress = true
+	return a.finishInitConfig(cfg)
+}
 
+// newInitConfig 创建待初始化的配置，保留已有的profile，其他配置项使用默认值
+func (a *App) newInitConfig() (*config.Config, error) {
+	// 保留已有的profile，只初始化当前（或 --profile 指定的）profile
+	cfg := config.Default()
+	if a.config != nil {
+		cfg.CopyProfiles(a.config)
+	}
+	if a.profile != "" {
+		if err := cfg.AddProfile(a.profile); err != nil {
+			return nil, err
+		}
+	} else if err := cfg.UseProfile(cfg.Profile); err != nil {
+		return nil, err
+	}
+	if cfg.KeyPrefix == "" && cfg.KeyTemplate == "" {
+		cfg.KeyPrefix = qiniu.DefaultKeyPrefix
+		cfg.KeyTemplate = qiniu.DefaultKeyTemplate
+	}
+
+	return cfg, nil
+}
+
+// finishInitConfig 保存初始化的配置并重新创建客户端
+func (a *App) finishInitConfig(cfg *config.Config) error {
 	// 保存配置
 	if err := config.Save(cfg); err != nil {
 		return fmt.Errorf("保存配置失败: %v", err)
 	}
 
+	path, _ := config.FilePath()
 	fmt.Println("\n✅ 配置保存成功!")
-	fmt.Println("配置文件位置: ~/.config/qu/config.yaml")
+	fmt.Println("配置文件位置:", path)
 
 	// 重新加载配置
 	a.config = cfg
+	a.linkFormat = cfg.LinkFormat
 
 	// 重新初始化七牛云客户端
-	qiniuConfig := &qiniu.Config{
-		AccessKey: cfg.QiniuAccessKey,
-		SecretKey: cfg.QiniuSecretKey,
-		Bucket:    cfg.QiniuBucket,
-		Domain:    cfg.QiniuDomain,
-	}
-	a.client = qiniu.NewClient(qiniuConfig)
+	a.resetClient()
 
 	return nil
 }
@@ -199,22 +340,26 @@ func (a *App) showConfig() error {
 	fmt.Println("\n🔧 当前配置:")
 	fmt.Println("=" + strings.Repeat("=", 50))
 
-	// 七牛云配置
-	fmt.Println("📋 七牛云配置:")
-	if a.config.QiniuAccessKey != "" {
-		fmt.Printf("  Access Key: %s*** (已设置)\n", a.config.QiniuAccessKey[:4])
-	} else {
-		fmt.Println("  Access Key: 未设置")
+	// 配置文件
+	if path, err := config.FilePath(); err == nil {
+		fmt.Printf("📄 配置文件: %s\n", path)
 	}
-
-	if a.config.QiniuSecretKey != "" {
-		fmt.Printf("  Secret Key: %s*** (已设置)\n", a.config.QiniuSecretKey[:4])
-	} else {
-		fmt.Println("  Secret Key: 未设置")
+	if path := a.config.ProjectFile(); path != "" {
+		fmt.Printf("📁 项目配置: %s\n", path)
 	}
 
+	// 七牛云配置
+	fmt.Printf("👤 Profile: %s\n", a.config.ProfileName())
+	fmt.Println("📋 七牛云配置:")
+	// 显示密钥掩码和来源前先解析密钥
+	a.qiniuClient()
+	accessKeyInfo, secretKeyInfo := a.config.CredentialSources()
+	printCredential("Access Key", a.config.QiniuAccessKey, accessKeyInfo)
+	printCredential("Secret Key", a.config.QiniuSecretKey, secretKeyInfo)
+
 	fmt.Printf("  Bucket: %s\n", a.config.QiniuBucket)
 	fmt.Printf("  域名: %s\n", a.config.QiniuDomain)
+	fmt.Printf("  Key 规则: %s (前缀 %s)\n", a.config.KeyTemplate, a.config.KeyPrefix)
 
 	// 快捷键配置
 	fmt.Println("\n⌨️  快捷键配置:")
@@ -239,6 +384,41 @@ func (a *App) showConfig() error {
 	fmt.Println("\n🎨 UI配置:")
 	fmt.Printf("  自动复制链接: %v\n", a.config.AutoCopyURL)
 	fmt.Printf("  显示进度条: %v\n", a.config.ShowProgress)
+	fmt.Printf("  链接格式: %s\n", a.config.LinkFormat)
+	if tool, err := clipboard.New(a.config.ClipboardCommand); err == nil {
+		fmt.Printf("  剪贴板工具: %s\n", tool.Name)
+	} else {
+		fmt.Printf("  剪贴板工具: 不可用 (%v)\n", err)
+	}
+
+	// 上传预处理配置
+	fmt.Println("\n🖼️  上传预处理:")
+	fmt.Printf("  移除元数据: %v\n", a.config.StripMetadata)
+	if a.config.ResizeMaxWidth > 0 || a.config.ResizeMaxHeight > 0 {
+		fmt.Printf("  最大尺寸: %s\n", qiniu.FormatImageSize(a.config.ResizeMaxWidth, a.config.ResizeMaxHeight))
+	}
+	if a.config.JPEGQuality > 0 {
+		fmt.Printf("  JPEG质量: %d\n", a.config.JPEGQuality)
+	}
+	if a.config.PNGOptimize {
+		fmt.Println("  PNG优化: true")
+	}
+	if a.config.AutoConvert != "" {
+		fmt.Printf("  格式转换: %s\n", a.config.AutoConvert)
+	}
+
+	// 合并用户配置、profile、项目配置和环境变量后的生效值
+	fmt.Println("\n📄 生效配置及来源:")
+	for _, f := range config.Schema {
+		value, err := a.config.Get(f.Key)
+		if err != nil {
+			continue
+		}
+		if f.Secret && value != "" && !secret.IsReference(value) {
+			value = maskSecret(value)
+		}
+		fmt.Printf("  %s = %q  (%s)\n", f.Key, value, a.config.Source(f.Key))
+	}
 
 	fmt.Println("=" + strings.Repeat("=", 50))
 
@@ -251,4 +431,4 @@ func (a *App) startService() error {
 	fmt.Println("当前版本暂不支持后台服务模式")
 	fmt.Println("请使用 'qu upload' 进入交互模式")
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"qiniu-uploader/internal/clipboard"
+)
+
+// clipboardSource 剪贴板上传在历史记录中的来源前缀
+const clipboardSource = "clipboard:"
+
+// pngSignature PNG文件头
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// newPasteCommand 创建剪贴板图片上传命令
+func (a *App) newPasteCommand() *cobra.Command {
+	var linkFormat string
+
+	cmd := &cobra.Command{
+		Use:   "paste",
+		Short: "上传剪贴板中的图片",
+		Long:  "读取剪贴板中的图片（截图等），以PNG格式上传，并将链接复制回剪贴板",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if linkFormat != "" {
+				if _, err := NewLinkFormatter(linkFormat); err != nil {
+					return err
+				}
+				a.linkFormat = linkFormat
+			}
+			return a.uploadClipboardImage()
+		},
+	}
+
+	cmd.Flags().StringVar(&linkFormat, "format", "", "链接输出格式: url|markdown|html|bbcode|json 或Go模板")
+	cmd.Flags().StringVarP(&a.uploadOpts.key, "key", "k", "", "指定存储key（已存在时覆盖）")
+	cmd.Flags().StringVar(&a.uploadOpts.convert, "convert", "", "上传前转换为指定格式 (webp、avif、none)")
+
+	return cmd
+}
+
+// uploadClipboardImage 读取剪贴板图片，保存为临时PNG文件后上传
+func (a *App) uploadClipboardImage() error {
+	if a.qiniuClient() == nil {
+		return fmt.Errorf("七牛云客户端未初始化，请先运行 'qu config init' 配置七牛云信息")
+	}
+
+	command := ""
+	if a.config != nil {
+		command = a.config.ClipboardPasteCommand
+	}
+	tool, err := clipboard.NewImagePaste(command)
+	if err != nil {
+		return err
+	}
+
+	data, err := tool.PasteImage()
+	if err != nil {
+		return err
+	}
+	data, err = ensurePNG(data)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "qu-paste-")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := fmt.Sprintf("paste-%s.png", time.Now().Format("20060102-150405"))
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("保存剪贴板图片失败: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "📋 已读取剪贴板图片 (%s, %.2f KB)\n", tool.Name, float64(len(data))/1024)
+
+	// 链接总是复制回剪贴板，不受 auto_copy_url 影响
+	a.uploadOpts.alwaysCopy = true
+	defer func() { a.uploadOpts.alwaysCopy = false }()
+	return a.uploadFileAs(path, clipboardSource+name)
+}
+
+// ensurePNG 确保数据为PNG格式，其他可解码格式会重新编码为PNG
+func ensurePNG(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, pngSignature) {
+		return data, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("剪贴板中没有可识别的图片: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("编码PNG失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"qiniu-uploader/internal/config"
+)
+
+// useProfile 设置默认使用的profile并保存到配置文件
+func (a *App) useProfile(name string) error {
+	if err := a.switchProfile(name); err != nil {
+		return err
+	}
+
+	a.config.CurrentProfile = a.config.Profile
+	if err := config.Save(a.config); err != nil {
+		return fmt.Errorf("保存配置失败: %v", err)
+	}
+
+	fmt.Printf("✅ 默认profile已切换为: %s\n", a.config.ProfileName())
+	return nil
+}
+
+// listProfiles 列出全部profile，* 表示当前生效的profile
+func (a *App) listProfiles() error {
+	if a.config == nil {
+		return fmt.Errorf("配置未加载，请先运行 'qu config init' 初始化配置")
+	}
+
+	fmt.Println("\n👤 Profile 列表:")
+	fmt.Println("-" + strings.Repeat("-", 50))
+
+	current := a.config.ProfileName()
+	startup := a.config.CurrentProfile
+	if startup == "" {
+		startup = config.DefaultProfile
+	}
+	for _, name := range a.config.ProfileNames() {
+		account, err := a.config.ProfileAccount(name)
+		if err != nil {
+			return err
+		}
+
+		marker := " "
+		if name == current {
+			marker = "*"
+		}
+		label := name
+		if name == startup {
+			label += " (默认)"
+		}
+		fmt.Printf("%s %s\n", marker, label)
+		fmt.Printf("    Bucket: %s | 域名: %s\n", valueOrUnset(account.QiniuBucket), valueOrUnset(account.QiniuDomain))
+		fmt.Printf("    Key: %s (前缀 %s)\n", valueOrUnset(account.KeyTemplate), valueOrUnset(account.KeyPrefix))
+	}
+
+	fmt.Println("\n💡 使用 'qu config use <profile>' 切换默认profile，或 'qu --profile <profile> ...' 临时使用")
+	return nil
+}
+
+// interactiveProfile 交互模式中查看或切换当前会话的profile
+func (a *App) interactiveProfile(name string) {
+	if name == "" {
+		if err := a.listProfiles(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+		}
+		return
+	}
+
+	if err := a.switchProfile(name); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	fmt.Printf("✅ 已切换到profile: %s (Bucket: %s)\n", a.config.ProfileName(), valueOrUnset(a.config.QiniuBucket))
+	if a.qiniuClient() == nil {
+		fmt.Println("⚠️  该profile的七牛云配置不完整，请运行 'qu --profile " + name + " config init'")
+	}
+}
+
+// valueOrUnset 空值显示为"未设置"
+func valueOrUnset(value string) string {
+	if value == "" {
+		return "未设置"
+	}
+	return value
+}
@@ -2,8 +2,11 @@ package cli
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"time"
+
+	"qiniu-uploader/internal/clipboard"
 )
 
 // ProgressBar 进度条
@@ -134,16 +137,33 @@ func SimpleProgress(message string, done chan bool) {
 	}
 }
 
-// CopyToClipboard 复制到剪贴板（平台特定实现）
-func CopyToClipboard(text string) error {
-	// 在实际实现中，这里会根据不同平台调用相应的剪贴板命令
-	// 例如：
-	// - Windows: echo text | clip
-	// - macOS: echo text | pbcopy
-	// - Linux: echo text | xclip -selection clipboard
+// CopyToClipboard 复制文本到剪贴板，command 为自定义剪贴板命令（文本通过stdin传入），
+// 为空时自动检测平台对应的剪贴板工具
+func CopyToClipboard(command, text string) error {
+	tool, err := clipboard.New(command)
+	if err != nil {
+		return err
+	}
+	return tool.Copy(text)
+}
 
-	fmt.Printf("📋 云端链接已复制到剪贴板: %s\n", text)
-	fmt.Println("💡 提示: 您可以直接粘贴使用该链接")
+// copyToClipboard 按配置复制文本到剪贴板，未开启 auto_copy_url 时跳过（qu paste 除外）
+func (a *App) copyToClipboard(text string) {
+	if a.config != nil && !a.config.AutoCopyURL && !a.uploadOpts.alwaysCopy {
+		return
+	}
 
-	return nil
-}
\ No newline at end of file
+	if err := CopyToClipboard(a.clipboardCommand(), text); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  复制到剪贴板失败: %v\n", err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, "📋 已复制到剪贴板")
+}
+
+// clipboardCommand 配置的剪贴板命令，为空时自动检测
+func (a *App) clipboardCommand() string {
+	if a.config == nil {
+		return ""
+	}
+	return a.config.ClipboardCommand
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"qiniu-uploader/internal/config"
+)
+
+// writeFakeClipboard 创建把stdin写入 output 的假剪贴板命令
+func writeFakeClipboard(t *testing.T) (script, output string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake clipboard command requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	output = filepath.Join(dir, "clipboard.txt")
+	script = filepath.Join(dir, "fake-clip")
+	content := fmt.Sprintf("#!/bin/sh\ncat > %q\n", output)
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return script, output
+}
+
+func TestCopyToClipboard(t *testing.T) {
+	script, output := writeFakeClipboard(t)
+
+	if err := CopyToClipboard(script, "https://example.com/a.png"); err != nil {
+		t.Fatalf("CopyToClipboard() error: %v", err)
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "https://example.com/a.png" {
+		t.Errorf("clipboard content = %q", got)
+	}
+
+	if err := CopyToClipboard("definitely-not-a-clipboard-tool", "x"); err == nil {
+		t.Error("expected error for missing clipboard command")
+	}
+}
+
+func TestCopyToClipboardAutoCopy(t *testing.T) {
+	tests := []struct {
+		name       string
+		autoCopy   bool
+		alwaysCopy bool
+		copied     bool
+	}{
+		{"Auto copy", true, false, true},
+		{"Disabled", false, false, false},
+		{"Paste always copies", false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, output := writeFakeClipboard(t)
+			cfg := config.Default()
+			cfg.ClipboardCommand = script
+			cfg.AutoCopyURL = tt.autoCopy
+			a := &App{config: cfg, uploadOpts: uploadOptions{alwaysCopy: tt.alwaysCopy}}
+
+			a.copyToClipboard("https://example.com/b.png")
+			_, err := os.Stat(output)
+			if copied := err == nil; copied != tt.copied {
+				t.Errorf("copied = %v, expected %v", copied, tt.copied)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/secret"
+)
+
+// storeCredentials 将 keys 中新输入的明文密钥保存到密钥环或加密文件，配置中只保留引用。
+// backend 为空时 prompt 为 true 则询问存储方式，否则返回错误；
+// 未在 keys 中的密钥（如来自环境变量或已有配置）和 env:/cmd:/keyring:/file: 引用保持不变
+func storeCredentials(cfg *config.Config, backend string, keys []string, prompt bool) error {
+	fields := map[string]*string{
+		"qiniu_access_key": &cfg.QiniuAccessKey,
+		"qiniu_secret_key": &cfg.QiniuSecretKey,
+	}
+
+	pending := map[string]*string{}
+	for _, key := range keys {
+		value, ok := fields[key]
+		if ok && *value != "" && !secret.IsReference(*value) {
+			pending[strings.TrimPrefix(key, "qiniu_")] = value
+		}
+	}
+
+	if len(pending) > 0 {
+		if backend == "" {
+			if !prompt {
+				return fmt.Errorf("明文密钥需要指定存储方式，请使用 --secret-store keyring|file|plain")
+			}
+			backend = promptSecretBackend()
+		}
+		for _, field := range []string{"access_key", "secret_key"} {
+			value, ok := pending[field]
+			if !ok {
+				continue
+			}
+			ref, err := config.Secrets().Store(backend, cfg.ProfileName()+"/"+field, *value)
+			if err != nil {
+				return err
+			}
+			*value = ref
+		}
+	}
+	return nil
+}
+
+// promptSecretBackend 询问密钥存储方式，默认优先使用系统密钥环
+func promptSecretBackend() string {
+	backend := secret.BackendKeyring
+	if !secret.KeyringAvailable() {
+		backend = secret.BackendFile
+	}
+
+	if input := readLine(fmt.Sprintf("密钥存储方式 [keyring/file/plain] (回车使用 %s): ", backend)); input != "" {
+		backend = input
+	}
+	return backend
+}
+
+// printCredential 显示密钥的掩码和来源
+func printCredential(label, value string, info config.SecretInfo) {
+	switch {
+	case info.Err != nil:
+		fmt.Printf("  %s: 解析失败 (%s: %v)\n", label, info.Source, info.Err)
+	case value == "":
+		fmt.Printf("  %s: 未设置\n", label)
+	default:
+		fmt.Printf("  %s: %s (来源: %s)\n", label, maskSecret(value), info.Source)
+	}
+}
+
+// maskSecret 只显示密钥的前4个字符
+func maskSecret(value string) string {
+	if len(value) > 4 {
+		value = value[:4]
+	}
+	return value + "***"
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"qiniu-uploader/internal/config"
+)
+
+func TestStoreCredentials(t *testing.T) {
+	tests := []struct {
+		name      string
+		secretKey string
+		backend   string
+		keys      []string
+		expected  string
+		errPart   string
+	}{
+		{"Not explicitly set", "plain-sk", "", nil, "plain-sk", ""},
+		{"Reference kept", "env:QINIU_SK", "", []string{"qiniu_secret_key"}, "env:QINIU_SK", ""},
+		{"Plain backend", "plain-sk", "plain", []string{"qiniu_secret_key"}, "plain-sk", ""},
+		{"Missing backend", "plain-sk", "", []string{"qiniu_secret_key"}, "plain-sk", "--secret-store"},
+		{"Unknown backend", "plain-sk", "vault", []string{"qiniu_secret_key"}, "plain-sk", "未知的密钥存储方式"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.QiniuSecretKey = tt.secretKey
+
+			err := storeCredentials(cfg, tt.backend, tt.keys, false)
+			if tt.errPart != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+					t.Errorf("storeCredentials() error = %v, expected to contain %q", err, tt.errPart)
+				}
+			} else if err != nil {
+				t.Fatalf("storeCredentials() error: %v", err)
+			}
+			if cfg.QiniuSecretKey != tt.expected {
+				t.Errorf("QiniuSecretKey = %q, expected %q", cfg.QiniuSecretKey, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/history"
+	"qiniu-uploader/internal/server"
+)
+
+// serveOptions HTTP服务命令选项，未指定时使用配置中的 host、port、listen、gin_mode
+type serveOptions struct {
+	host   string
+	port   int
+	listen string
+	mode   string
+}
+
+// newServeCommand 创建HTTP服务命令
+func (a *App) newServeCommand() *cobra.Command {
+	var opts serveOptions
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "启动HTTP上传服务",
+		Long: `启动HTTP上传服务，提供 POST /api/upload、GET /api/images 和浏览器直传接口 POST /api/token。
+接口需要使用 'qu token create' 创建的API令牌认证。
+收到 SIGINT 或 SIGTERM 后停止接受新请求，在 shutdown_timeout 秒内等待进行中的上传完成后退出。
+使用与命令行相同的配置、profile和项目配置，参数优先于配置文件中的 host、port、listen、gin_mode。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.config == nil {
+				return fmt.Errorf("配置未加载，请先运行 'qu config init' 初始化配置")
+			}
+
+			// 命令行参数只对本次运行生效，不写入配置文件
+			flags := map[string]string{}
+			if cmd.Flags().Changed("host") {
+				flags["host"] = opts.host
+			}
+			if cmd.Flags().Changed("port") {
+				flags["port"] = strconv.Itoa(opts.port)
+			}
+			if cmd.Flags().Changed("listen") {
+				flags["listen"] = opts.listen
+			}
+			if cmd.Flags().Changed("mode") {
+				flags["gin_mode"] = opts.mode
+			}
+			for key, value := range flags {
+				if err := a.config.Set(key, value); err != nil {
+					return err
+				}
+			}
+
+			return a.startServer()
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.host, "host", "", "监听地址（默认 127.0.0.1，0.0.0.0 表示全部网卡）")
+	cmd.Flags().IntVar(&opts.port, "port", 0, "监听端口（默认 8080）")
+	cmd.Flags().StringVar(&opts.listen, "listen", "", "监听方式: unix:<路径> 监听Unix套接字，systemd 使用套接字激活（默认监听 host:port）")
+	cmd.Flags().StringVar(&opts.mode, "mode", "", "运行模式: debug|release|test（默认 release）")
+
+	return cmd
+}
+
+// startServer 使用当前配置启动HTTP服务
+func (a *App) startServer() error {
+	if a.qiniuClient() == nil {
+		return fmt.Errorf("七牛云客户端未初始化，请先运行 'qu config init' 配置七牛云信息")
+	}
+
+	tokens, err := a.tokenStore()
+	if err != nil {
+		return err
+	}
+
+	// 浏览器直传记录到单独的文件，不混入本机命令行的上传历史
+	var hist *history.Store
+	if dir, err := config.Dir(); err == nil {
+		hist = history.OpenServer(dir)
+	}
+	srv := server.NewServer(a.config, tokens, hist)
+	srv.Setup()
+
+	fmt.Printf("🌐 HTTP上传服务 (profile: %s, 存储空间: %s)\n", a.config.ProfileName(), a.config.QiniuBucket)
+	if !hasActiveToken(tokens) {
+		fmt.Println("⚠️  尚未创建API令牌，所有API请求都会被拒绝，请运行 'qu token create' 创建")
+	}
+	return srv.Start()
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"qiniu-uploader/internal/history"
+	"qiniu-uploader/internal/token"
+)
+
+// tokenCreateOptions 创建API令牌命令选项
+type tokenCreateOptions struct {
+	name    string
+	scopes  []string
+	expires string
+	hmac    bool
+}
+
+// newTokenCommand 创建API令牌管理命令
+func (a *App) newTokenCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "管理HTTP上传服务的API令牌",
+		Long: `管理 'qu serve' 的API令牌。令牌只保存SHA-256摘要，创建后只显示一次。
+请求时使用 Authorization: Bearer <令牌>，或使用 --hmac 创建的令牌对请求签名。`,
+	}
+
+	var opts tokenCreateOptions
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "创建API令牌",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.createToken(opts)
+		},
+	}
+	createCmd.Flags().StringVar(&opts.name, "name", "", "令牌名称，便于识别和撤销")
+	createCmd.Flags().StringSliceVar(&opts.scopes, "scope", nil, "令牌权限: upload,list,delete（默认 upload）")
+	createCmd.Flags().StringVar(&opts.expires, "expires", "", "有效期，如 12h、30d、2w（默认永久有效）")
+	createCmd.Flags().BoolVar(&opts.hmac, "hmac", false, "只允许HMAC签名请求，用于服务端之间调用")
+	cmd.AddCommand(createCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "列出API令牌",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.listTokens()
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "revoke <id|name>",
+		Short: "撤销API令牌，立即生效",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.revokeToken(args[0])
+		},
+	})
+
+	return cmd
+}
+
+// tokenStore 返回API令牌存储，配置目录不可用时返回错误
+func (a *App) tokenStore() (*token.Store, error) {
+	if a.tokens == nil {
+		return nil, fmt.Errorf("无法访问配置目录，API令牌不可用")
+	}
+	return a.tokens, nil
+}
+
+// createToken 创建令牌并输出令牌字符串
+func (a *App) createToken(opts tokenCreateOptions) error {
+	store, err := a.tokenStore()
+	if err != nil {
+		return err
+	}
+	ttl, err := history.ParseSince(opts.expires)
+	if err != nil {
+		return fmt.Errorf("无效的有效期: %v", err)
+	}
+
+	raw, t, err := store.Create(token.CreateOptions{
+		Name:   opts.name,
+		Scopes: opts.scopes,
+		TTL:    ttl,
+		HMAC:   opts.hmac,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ 已创建API令牌 %s\n", t.ID)
+	fmt.Printf("🔑 令牌: %s\n", raw)
+	fmt.Printf("🛡️  权限: %s\n", strings.Join(t.Scopes, ","))
+	if !t.ExpiresAt.IsZero() {
+		fmt.Printf("⏰ 过期时间: %s\n", t.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if t.HMAC() {
+		fmt.Printf("✍️  签名密钥: %s\n", t.SigningKey)
+		fmt.Printf("   请求需携带 %s、%s、%s、%s\n",
+			token.HeaderTokenID, token.HeaderTimestamp, token.HeaderContentSHA256, token.HeaderSignature)
+	}
+	fmt.Println("⚠️  令牌只显示这一次，请妥善保存")
+	return nil
+}
+
+// listTokens 列出全部令牌
+func (a *App) listTokens() error {
+	store, err := a.tokenStore()
+	if err != nil {
+		return err
+	}
+	tokens, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		fmt.Println("  暂无API令牌，使用 'qu token create' 创建")
+		return nil
+	}
+
+	fmt.Println("\n🔑 API令牌:")
+	fmt.Println("-" + strings.Repeat("-", 80))
+	now := time.Now()
+	for _, t := range tokens {
+		name := t.Name
+		if name == "" {
+			name = "-"
+		}
+		mode := "Bearer"
+		if t.HMAC() {
+			mode = "HMAC"
+		}
+		fmt.Printf("%s  %-16s %-20s %-6s 创建于 %s  %s\n",
+			t.ID, name, strings.Join(t.Scopes, ","), mode,
+			t.CreatedAt.Local().Format("2006-01-02 15:04"), tokenState(&t, now))
+	}
+	return nil
+}
+
+// tokenState 返回令牌状态描述
+func tokenState(t *token.Token, now time.Time) string {
+	switch {
+	case !t.RevokedAt.IsZero():
+		return "❌ 已撤销于 " + t.RevokedAt.Local().Format("2006-01-02 15:04")
+	case t.ExpiresAt.IsZero():
+		return "✅ 永久有效"
+	case now.After(t.ExpiresAt):
+		return "⏰ 已过期于 " + t.ExpiresAt.Local().Format("2006-01-02 15:04")
+	default:
+		return "✅ 有效期至 " + t.ExpiresAt.Local().Format("2006-01-02 15:04")
+	}
+}
+
+// revokeToken 撤销令牌
+func (a *App) revokeToken(idOrName string) error {
+	store, err := a.tokenStore()
+	if err != nil {
+		return err
+	}
+	t, err := store.Revoke(idOrName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ 已撤销API令牌 %s\n", t.ID)
+	return nil
+}
+
+// hasActiveToken 判断是否存在可用的API令牌
+func hasActiveToken(store *token.Store) bool {
+	tokens, err := store.List()
+	if err != nil {
+		return false
+	}
+	for _, t := range tokens {
+		if t.Active(time.Now()) == nil {
+			return true
+		}
+	}
+	return false
+}
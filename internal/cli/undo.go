@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"qiniu-uploader/internal/history"
+	"qiniu-uploader/pkg/qiniu"
+)
+
+// newUndoCommand 创建撤销上传命令
+func (a *App) newUndoCommand() *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "undo [N]",
+		Short: "撤销最近的上传",
+		Long:  "删除本机最近一次（或最近 N 次）上传的文件，只会删除上传历史中记录的文件。\n上传时覆盖了已有文件的记录需要单独确认，删除后原有文件无法恢复",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := parseUndoCount(args)
+			if err != nil {
+				return err
+			}
+
+			client := a.qiniuClient()
+			if client == nil {
+				return fmt.Errorf("七牛云客户端未初始化，请先运行 'qu config init' 配置七牛云信息")
+			}
+
+			// 覆盖上传的文件总是单独确认，--yes 时跳过这些文件
+			confirm, confirmOverwritten := confirmFromStdin, confirmFromStdin
+			if yes {
+				confirm = func(string) bool { return true }
+				confirmOverwritten = func(string) bool { return false }
+			}
+			return a.undoUploads(client, n, confirm, confirmOverwritten)
+		},
+	}
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "跳过确认直接删除（不包括覆盖了已有文件的上传）")
+
+	return cmd
+}
+
+// parseUndoCount 解析需要撤销的上传数量，默认为1
+func parseUndoCount(args []string) (int, error) {
+	if len(args) == 0 || args[0] == "" {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("无效的数量: %s", args[0])
+	}
+	return n, nil
+}
+
+// confirmFromStdin 从标准输入读取确认
+func confirmFromStdin(prompt string) bool {
+	return isYes(readLine(prompt))
+}
+
+// isYes 判断输入是否为确认
+func isYes(input string) bool {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "y", "yes", "是":
+		return true
+	}
+	return false
+}
+
+// undoClient 撤销上传需要的七牛云操作
+type undoClient interface {
+	Stat(key string) (*qiniu.FileInfo, error)
+	Delete(key string) error
+}
+
+// undoUploads 删除最近 n 次上传的文件，confirm 用于确认删除，
+// confirmOverwritten 用于单独确认上传时覆盖了已有文件的记录
+func (a *App) undoUploads(client undoClient, n int, confirm, confirmOverwritten func(prompt string) bool) error {
+	store, err := a.historyStore()
+	if err != nil {
+		return err
+	}
+
+	entries, err := store.All()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("  暂无可撤销的上传记录")
+		return nil
+	}
+	if n > len(entries) {
+		n = len(entries)
+	}
+
+	// 只处理当前存储空间中的记录，覆盖上传的记录单独列出
+	var targets, overwritten []history.Entry
+	for _, e := range entries[:n] {
+		if e.Bucket != "" && a.config != nil && e.Bucket != a.config.QiniuBucket {
+			fmt.Printf("⏭️  跳过 %s（属于存储空间 %s）\n", e.Key, e.Bucket)
+			continue
+		}
+		if e.Overwritten {
+			overwritten = append(overwritten, e)
+			continue
+		}
+		targets = append(targets, e)
+	}
+
+	if len(targets) > 0 {
+		fmt.Printf("\n🗑️  即将删除以下 %d 个文件:\n", len(targets))
+		printUndoEntries(targets)
+		if !confirm("\n确认删除? [y/N]: ") {
+			fmt.Println("已取消")
+			return nil
+		}
+	}
+	if len(overwritten) > 0 {
+		fmt.Printf("\n⚠️  以下 %d 个文件上传时覆盖了云端已有的文件，删除后原有文件无法恢复:\n", len(overwritten))
+		printUndoEntries(overwritten)
+		if confirmOverwritten("\n确认同时删除这些文件? [y/N]: ") {
+			targets = append(targets, overwritten...)
+		} else {
+			fmt.Println("已跳过覆盖上传的文件")
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	var removed []history.Entry
+	deleted := 0
+	for _, e := range targets {
+		// 云端文件已被替换时不删除，避免误删其他来源的文件
+		info, err := client.Stat(e.Key)
+		if err == qiniu.ErrNotFound {
+			fmt.Printf("⚠️  %s 已不存在，移除历史记录\n", e.Key)
+			removed = append(removed, e)
+			continue
+		}
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", e.Key, err)
+			continue
+		}
+		if e.Hash != "" && info.Hash != e.Hash {
+			fmt.Printf("⏭️  跳过 %s（云端文件已被修改，不是本次上传的内容）\n", e.Key)
+			continue
+		}
+
+		if err := client.Delete(e.Key); err != nil && err != qiniu.ErrNotFound {
+			fmt.Printf("❌ 删除 %s 失败: %v\n", e.Key, err)
+			continue
+		}
+		fmt.Printf("✅ 已删除: %s\n", e.Key)
+		removed = append(removed, e)
+		deleted++
+	}
+
+	if len(removed) > 0 {
+		if err := store.Remove(removed...); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("\n🧹 共删除 %d 个文件\n", deleted)
+	if deleted > 0 {
+		fmt.Println("💡 提示: CDN可能仍缓存已删除的文件，可使用 'qu cdn refresh' 刷新")
+	}
+	return nil
+}
+
+// printUndoEntries 列出待删除的上传记录
+func printUndoEntries(entries []history.Entry) {
+	for i, e := range entries {
+		fmt.Printf("%3d. %s\n", i+1, e.Key)
+		fmt.Printf("     链接: %s\n", e.URL)
+		fmt.Printf("     本地: %s\n", e.LocalPath)
+	}
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/history"
+	"qiniu-uploader/pkg/qiniu"
+)
+
+func TestParseUndoCount(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected int
+		wantErr  bool
+	}{
+		{nil, 1, false},
+		{[]string{"3"}, 3, false},
+		{[]string{"0"}, 0, true},
+		{[]string{"-2"}, 0, true},
+		{[]string{"all"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseUndoCount(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseUndoCount(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("parseUndoCount(%v) = %d, expected %d", tt.args, got, tt.expected)
+		}
+	}
+}
+
+func TestIsYes(t *testing.T) {
+	for _, input := range []string{"y", "Y\n", " yes ", "是"} {
+		if !isYes(input) {
+			t.Errorf("isYes(%q) = false, expected true", input)
+		}
+	}
+	for _, input := range []string{"", "n", "no", "yep"} {
+		if isYes(input) {
+			t.Errorf("isYes(%q) = true, expected false", input)
+		}
+	}
+}
+
+// fakeUndoClient 记录删除操作的七牛云客户端，files 为云端文件的hash
+type fakeUndoClient struct {
+	files   map[string]string
+	deleted []string
+}
+
+func (f *fakeUndoClient) Stat(key string) (*qiniu.FileInfo, error) {
+	hash, ok := f.files[key]
+	if !ok {
+		return nil, qiniu.ErrNotFound
+	}
+	return &qiniu.FileInfo{Key: key, Hash: hash}, nil
+}
+
+func (f *fakeUndoClient) Delete(key string) error {
+	f.deleted = append(f.deleted, key)
+	delete(f.files, key)
+	return nil
+}
+
+func TestUndoUploads(t *testing.T) {
+	tests := []struct {
+		name              string
+		confirmOverwrite  bool
+		expectedDeleted   []string
+		expectedRemaining []string
+	}{
+		{"Overwrite declined", false, []string{"mine.png"}, []string{"logo.png", "changed.png", "other.png"}},
+		{"Overwrite confirmed", true, []string{"mine.png", "logo.png"}, []string{"changed.png", "other.png"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := history.NewStore(filepath.Join(t.TempDir(), history.FileName))
+			for _, e := range []history.Entry{
+				{Key: "other.png", Hash: "h", Bucket: "other"},
+				{Key: "gone.png", Hash: "h", Bucket: "assets"},
+				{Key: "changed.png", Hash: "h", Bucket: "assets"},
+				{Key: "mine.png", Hash: "h", Bucket: "assets"},
+				{Key: "logo.png", Hash: "h", Bucket: "assets", Overwritten: true},
+			} {
+				if err := store.Add(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+			client := &fakeUndoClient{files: map[string]string{
+				"other.png":   "h",
+				"changed.png": "h2",
+				"mine.png":    "h",
+				"logo.png":    "h",
+			}}
+			cfg := config.Default()
+			cfg.QiniuBucket = "assets"
+			a := &App{config: cfg, history: store}
+
+			confirm := func(string) bool { return true }
+			confirmOverwritten := func(string) bool { return tt.confirmOverwrite }
+			if err := a.undoUploads(client, 5, confirm, confirmOverwritten); err != nil {
+				t.Fatalf("undoUploads() error: %v", err)
+			}
+
+			if !reflect.DeepEqual(client.deleted, tt.expectedDeleted) {
+				t.Errorf("deleted = %v, expected %v", client.deleted, tt.expectedDeleted)
+			}
+			entries, err := store.All()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var remaining []string
+			for _, e := range entries {
+				remaining = append(remaining, e.Key)
+			}
+			if !reflect.DeepEqual(remaining, tt.expectedRemaining) {
+				t.Errorf("remaining history = %v, expected %v", remaining, tt.expectedRemaining)
+			}
+		})
+	}
+}
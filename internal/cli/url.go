@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"qiniu-uploader/pkg/qiniu"
+)
+
+// urlOptions 图片处理URL选项
+type urlOptions struct {
+	thumb          string
+	resize         string
+	crop           string
+	gravity        string
+	format         string
+	quality        int
+	strip          bool
+	rotate         int
+	blur           string
+	watermarkText  string
+	watermarkImage string
+	style          string
+	separator      string
+}
+
+// newURLCommand 创建图片处理URL命令
+func (a *App) newURLCommand() *cobra.Command {
+	opts := &urlOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "url <key>",
+		Short: "生成图片处理URL",
+		Long:  "基于七牛云 imageView2/imageMogr2/watermark 生成缩略图、格式转换、水印等处理链接",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.config == nil || a.config.QiniuBucket == "" {
+				return fmt.Errorf("七牛云客户端未初始化，请先运行 'qu config init' 配置七牛云信息")
+			}
+
+			// 生成链接只需要域名，不读取密钥
+			client := qiniu.NewClient(&qiniu.Config{Domain: a.config.QiniuDomain, StyleSeparator: a.config.StyleSeparator})
+			builder, err := opts.build(client.ImageURL(args[0]))
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(builder.String())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.thumb, "thumb", "", "生成居中裁剪的缩略图，如 300x300")
+	cmd.Flags().StringVar(&opts.resize, "resize", "", "等比缩放，如 1920x、x1080、800x600")
+	cmd.Flags().StringVar(&opts.crop, "crop", "", "裁剪指定大小，如 300x300")
+	cmd.Flags().StringVar(&opts.gravity, "gravity", "", "裁剪和水印的锚点位置，如 Center、SouthEast")
+	cmd.Flags().StringVar(&opts.format, "format", "", "输出格式，如 webp、jpg、png")
+	cmd.Flags().IntVarP(&opts.quality, "quality", "q", 0, "输出质量 (1-100)")
+	cmd.Flags().BoolVar(&opts.strip, "strip", false, "去除图片元信息")
+	cmd.Flags().IntVar(&opts.rotate, "rotate", 0, "顺时针旋转角度")
+	cmd.Flags().StringVar(&opts.blur, "blur", "", "高斯模糊，格式为 半径x标准差，如 20x5")
+	cmd.Flags().StringVar(&opts.watermarkText, "watermark-text", "", "文字水印内容")
+	cmd.Flags().StringVar(&opts.watermarkImage, "watermark-image", "", "图片水印URL")
+	cmd.Flags().StringVar(&opts.style, "style", "", "使用控制台中定义的图片样式名")
+	cmd.Flags().StringVar(&opts.separator, "separator", "", "样式分隔符 (- 或 !)，默认使用配置中的 style_separator")
+
+	return cmd
+}
+
+// build 根据选项设置构建器
+func (o *urlOptions) build(b *qiniu.ImageURLBuilder) (*qiniu.ImageURLBuilder, error) {
+	if o.separator != "" {
+		if o.separator != "-" && o.separator != "!" {
+			return nil, fmt.Errorf("不支持的样式分隔符: %s", o.separator)
+		}
+		b.Separator(o.separator)
+	}
+	if o.style != "" {
+		return b.Style(o.style), nil
+	}
+
+	if o.thumb != "" {
+		w, h, err := qiniu.ParseImageSize(o.thumb)
+		if err != nil {
+			return nil, err
+		}
+		b.Thumbnail(qiniu.ViewModeCrop, w, h)
+	}
+	if o.resize != "" {
+		w, h, err := qiniu.ParseImageSize(o.resize)
+		if err != nil {
+			return nil, err
+		}
+		b.Resize(w, h)
+	}
+	if o.crop != "" {
+		w, h, err := qiniu.ParseImageSize(o.crop)
+		if err != nil {
+			return nil, err
+		}
+		gravity := o.gravity
+		if gravity == "" {
+			gravity = qiniu.GravityCenter
+		}
+		b.Crop(w, h, gravity)
+	}
+	if o.format != "" {
+		b.Format(o.format)
+	}
+	if o.quality != 0 {
+		if o.quality < 1 || o.quality > 100 {
+			return nil, fmt.Errorf("质量参数必须在 1-100 之间")
+		}
+		b.Quality(o.quality)
+	}
+	if o.strip {
+		b.Strip()
+	}
+	if o.rotate != 0 {
+		b.Rotate(o.rotate)
+	}
+	if o.blur != "" {
+		var radius, sigma int
+		if _, err := fmt.Sscanf(o.blur, "%dx%d", &radius, &sigma); err != nil {
+			return nil, fmt.Errorf("无效的模糊参数: %s（示例: 20x5）", o.blur)
+		}
+		b.Blur(radius, sigma)
+	}
+	if o.watermarkText != "" {
+		b.TextWatermark(o.watermarkText, qiniu.Watermark{Gravity: o.gravity})
+	}
+	if o.watermarkImage != "" {
+		b.ImageWatermark(o.watermarkImage, qiniu.Watermark{Gravity: o.gravity})
+	}
+
+	return b, nil
+}
@@ -0,0 +1,276 @@
+package clipboard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"qiniu-uploader/internal/utils"
+)
+
+// Tool 剪贴板工具
+type Tool struct {
+	// Name 工具名称，用于显示
+	Name string
+	// CopyArgs 写入剪贴板的命令，文本通过stdin传入
+	CopyArgs []string
+	// PasteArgs 读取剪贴板PNG图片的命令，图片数据输出到stdout
+	PasteArgs []string
+	// pasteViaFile 为true时 PasteArgs 输出的是保存图片的Windows临时文件路径
+	pasteViaFile bool
+}
+
+// 以下变量便于测试时替换
+var (
+	lookPath = exec.LookPath
+	isWSL    = utils.IsWSLEnvironment
+	goos     = runtime.GOOS
+	getenv   = os.Getenv
+)
+
+// candidate 候选剪贴板工具
+type candidate struct {
+	name string
+	copy []string
+}
+
+// pasteCandidate 候选的剪贴板图片读取工具
+type pasteCandidate struct {
+	name    string
+	args    []string
+	viaFile bool
+}
+
+// powershellPasteScript 将剪贴板图片保存为临时PNG文件并输出路径
+const powershellPasteScript = `Add-Type -AssemblyName System.Drawing; ` +
+	`$img = Get-Clipboard -Format Image; ` +
+	`if ($img -eq $null) { exit 1 }; ` +
+	`$p = [System.IO.Path]::Combine([System.IO.Path]::GetTempPath(), [guid]::NewGuid().ToString() + '.png'); ` +
+	`$img.Save($p, [System.Drawing.Imaging.ImageFormat]::Png); ` +
+	`Write-Output $p`
+
+// pasteCandidates 根据平台返回按优先级排列的图片读取工具
+func pasteCandidates() []pasteCandidate {
+	powershell := func(name string) pasteCandidate {
+		return pasteCandidate{name, []string{name, "-NoProfile", "-Command", powershellPasteScript}, true}
+	}
+
+	switch goos {
+	case "darwin":
+		return []pasteCandidate{{"pngpaste", []string{"pngpaste", "-"}, false}}
+	case "windows":
+		return []pasteCandidate{powershell("powershell")}
+	case "linux":
+		var list []pasteCandidate
+		if isWSL() {
+			list = append(list, powershell("powershell.exe"))
+		}
+		if getenv("WAYLAND_DISPLAY") != "" {
+			list = append(list, pasteCandidate{"wl-paste", []string{"wl-paste", "-t", "image/png"}, false})
+		}
+		list = append(list, pasteCandidate{"xclip", []string{"xclip", "-selection", "clipboard", "-t", "image/png", "-o"}, false})
+		return list
+	}
+	return nil
+}
+
+// candidates 根据平台返回按优先级排列的候选工具
+func candidates() []candidate {
+	switch goos {
+	case "darwin":
+		return []candidate{{"pbcopy", []string{"pbcopy"}}}
+	case "windows":
+		return []candidate{{"clip", []string{"clip"}}}
+	case "linux":
+		var list []candidate
+		// WSL中优先使用Windows剪贴板
+		if isWSL() {
+			list = append(list,
+				candidate{"clip.exe", []string{"clip.exe"}},
+				candidate{"powershell.exe", []string{"powershell.exe", "-NoProfile", "-Command", "$input | Set-Clipboard"}},
+			)
+		}
+		if getenv("WAYLAND_DISPLAY") != "" {
+			list = append(list, candidate{"wl-copy", []string{"wl-copy"}})
+		}
+		list = append(list,
+			candidate{"xclip", []string{"xclip", "-selection", "clipboard"}},
+			candidate{"xsel", []string{"xsel", "--clipboard", "--input"}},
+		)
+		return list
+	}
+	return nil
+}
+
+// Detect 检测当前环境可用的剪贴板工具
+func Detect() (*Tool, error) {
+	var tried []string
+	for _, c := range candidates() {
+		if _, err := lookPath(c.copy[0]); err == nil {
+			return &Tool{Name: c.name, CopyArgs: c.copy}, nil
+		}
+		tried = append(tried, c.name)
+	}
+
+	if len(tried) == 0 {
+		return nil, fmt.Errorf("不支持的操作系统: %s", goos)
+	}
+	return nil, fmt.Errorf("未找到剪贴板工具，请安装以下任意一个: %s", strings.Join(tried, ", "))
+}
+
+// DetectImagePaste 检测当前环境可读取剪贴板图片的工具
+func DetectImagePaste() (*Tool, error) {
+	var tried []string
+	for _, c := range pasteCandidates() {
+		if _, err := lookPath(c.args[0]); err == nil {
+			return &Tool{Name: c.name, PasteArgs: c.args, pasteViaFile: c.viaFile}, nil
+		}
+		tried = append(tried, c.name)
+	}
+
+	if len(tried) == 0 {
+		return nil, fmt.Errorf("不支持的操作系统: %s", goos)
+	}
+	return nil, fmt.Errorf("未找到可读取剪贴板图片的工具，请安装以下任意一个: %s", strings.Join(tried, ", "))
+}
+
+// NewImagePaste 创建剪贴板图片读取工具，command 为空时自动检测，
+// 否则使用指定命令（需将PNG图片输出到stdout）
+func NewImagePaste(command string) (*Tool, error) {
+	args, err := splitCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return DetectImagePaste()
+	}
+	if _, err := lookPath(args[0]); err != nil {
+		return nil, fmt.Errorf("剪贴板命令不可用: %s", args[0])
+	}
+	return &Tool{Name: args[0], PasteArgs: args}, nil
+}
+
+// New 创建剪贴板工具，command 为空时自动检测，否则使用指定命令（如 "xclip -selection clipboard"）
+func New(command string) (*Tool, error) {
+	args, err := splitCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return Detect()
+	}
+	if _, err := lookPath(args[0]); err != nil {
+		return nil, fmt.Errorf("剪贴板命令不可用: %s", args[0])
+	}
+	return &Tool{Name: args[0], CopyArgs: args}, nil
+}
+
+// Copy 写入文本到剪贴板
+func (t *Tool) Copy(text string) error {
+	cmd := exec.Command(t.CopyArgs[0], t.CopyArgs[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s 写入剪贴板失败: %v %s", t.Name, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// PasteImage 读取剪贴板中的图片数据
+func (t *Tool) PasteImage() ([]byte, error) {
+	if len(t.PasteArgs) == 0 {
+		return nil, fmt.Errorf("%s 不支持读取剪贴板图片", t.Name)
+	}
+
+	cmd := exec.Command(t.PasteArgs[0], t.PasteArgs[1:]...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("剪贴板中没有图片 (%s: %v %s)", t.Name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	if !t.pasteViaFile {
+		if stdout.Len() == 0 {
+			return nil, fmt.Errorf("剪贴板中没有图片")
+		}
+		return stdout.Bytes(), nil
+	}
+
+	// PowerShell 输出的是Windows路径，WSL中需要转换
+	path := strings.TrimSpace(stdout.String())
+	if path == "" {
+		return nil, fmt.Errorf("剪贴板中没有图片")
+	}
+	if goos == "linux" {
+		converted, err := utils.ConvertWindowsPathToWSL(path)
+		if err != nil {
+			return nil, err
+		}
+		path = converted
+	}
+	defer os.Remove(path)
+	return os.ReadFile(path)
+}
+
+// splitCommand 按空白拆分命令行，支持单引号、双引号和反斜杠转义，如
+// sh -c 'xclip -selection clipboard'。反斜杠只转义引号、空白和反斜杠本身，
+// 因此 C:\tools\clip.exe 这样的Windows路径可以直接使用
+func splitCommand(command string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range command {
+		switch {
+		case escaped:
+			if !strings.ContainsRune("\"'\\ \t", r) {
+				current.WriteRune('\\')
+			}
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inArg = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("剪贴板命令中的引号不匹配: %s", command)
+	}
+	if escaped {
+		current.WriteRune('\\')
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,214 @@
+package clipboard
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// stubEnv 替换平台检测相关的变量，available 为可找到的命令
+func stubEnv(t *testing.T, platform string, wsl bool, env map[string]string, available ...string) {
+	t.Helper()
+	origLookPath, origIsWSL, origGOOS, origGetenv := lookPath, isWSL, goos, getenv
+	t.Cleanup(func() {
+		lookPath, isWSL, goos, getenv = origLookPath, origIsWSL, origGOOS, origGetenv
+	})
+
+	goos = platform
+	isWSL = func() bool { return wsl }
+	getenv = func(key string) string { return env[key] }
+	lookPath = func(name string) (string, error) {
+		for _, a := range available {
+			if a == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", fmt.Errorf("not found: %s", name)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name      string
+		goos      string
+		wsl       bool
+		env       map[string]string
+		available []string
+		expected  string
+	}{
+		{"macOS", "darwin", false, nil, []string{"pbcopy"}, "pbcopy"},
+		{"Windows", "windows", false, nil, []string{"clip"}, "clip"},
+		{"WSL clip.exe", "linux", true, nil, []string{"clip.exe", "xclip"}, "clip.exe"},
+		{"WSL powershell", "linux", true, nil, []string{"powershell.exe", "xclip"}, "powershell.exe"},
+		{"Wayland", "linux", false, map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, []string{"wl-copy", "xclip"}, "wl-copy"},
+		{"X11 without Wayland", "linux", false, nil, []string{"wl-copy", "xclip"}, "xclip"},
+		{"xsel fallback", "linux", false, nil, []string{"xsel"}, "xsel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubEnv(t, tt.goos, tt.wsl, tt.env, tt.available...)
+			tool, err := Detect()
+			if err != nil {
+				t.Fatalf("Detect() error: %v", err)
+			}
+			if tool.Name != tt.expected {
+				t.Errorf("Detect() = %s, expected %s", tool.Name, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDetectNotFound(t *testing.T) {
+	stubEnv(t, "linux", false, nil)
+	if _, err := Detect(); err == nil {
+		t.Error("expected error when no clipboard tool is installed")
+	}
+
+	stubEnv(t, "plan9", false, nil)
+	if _, err := Detect(); err == nil {
+		t.Error("expected error on unsupported OS")
+	}
+}
+
+func TestCopyWithFakeCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake clipboard command requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "clipboard.txt")
+	script := filepath.Join(dir, "fake-clip")
+	content := fmt.Sprintf("#!/bin/sh\ncat > %q\n", output)
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tool, err := New(script)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := tool.Copy("https://example.com/a.png"); err != nil {
+		t.Fatalf("Copy() error: %v", err)
+	}
+
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "https://example.com/a.png" {
+		t.Errorf("clipboard content = %q", got)
+	}
+}
+
+func TestNewWithMissingCommand(t *testing.T) {
+	if _, err := New("definitely-not-a-clipboard-tool --flag"); err == nil {
+		t.Error("expected error for missing command")
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command     string
+		expected    []string
+		shouldError bool
+	}{
+		{"", nil, false},
+		{"xclip -selection clipboard", []string{"xclip", "-selection", "clipboard"}, false},
+		{`sh -c 'xclip -selection clipboard'`, []string{"sh", "-c", "xclip -selection clipboard"}, false},
+		{`"/opt/my tools/copy" --name "a \"b\""`, []string{"/opt/my tools/copy", "--name", `a "b"`}, false},
+		{`/opt/my\ tools/copy ''`, []string{"/opt/my tools/copy", ""}, false},
+		{`C:\tools\clip.exe`, []string{`C:\tools\clip.exe`}, false},
+		{`sh -c 'unterminated`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got, err := splitCommand(tt.command)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("splitCommand(%q) expected error", tt.command)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitCommand(%q) error: %v", tt.command, err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.expected, "|") || len(got) != len(tt.expected) {
+				t.Errorf("splitCommand(%q) = %q, expected %q", tt.command, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDetectImagePaste(t *testing.T) {
+	tests := []struct {
+		name      string
+		goos      string
+		wsl       bool
+		env       map[string]string
+		available []string
+		expected  string
+	}{
+		{"macOS", "darwin", false, nil, []string{"pngpaste"}, "pngpaste"},
+		{"Windows", "windows", false, nil, []string{"powershell"}, "powershell"},
+		{"WSL", "linux", true, nil, []string{"powershell.exe", "xclip"}, "powershell.exe"},
+		{"Wayland", "linux", false, map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, []string{"wl-paste", "xclip"}, "wl-paste"},
+		{"X11", "linux", false, nil, []string{"wl-paste", "xclip"}, "xclip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubEnv(t, tt.goos, tt.wsl, tt.env, tt.available...)
+			tool, err := DetectImagePaste()
+			if err != nil {
+				t.Fatalf("DetectImagePaste() error: %v", err)
+			}
+			if tool.Name != tt.expected {
+				t.Errorf("DetectImagePaste() = %s, expected %s", tool.Name, tt.expected)
+			}
+		})
+	}
+
+	stubEnv(t, "linux", false, nil, "xsel")
+	if _, err := DetectImagePaste(); err == nil {
+		t.Error("expected error when only xsel is installed")
+	}
+}
+
+func TestPasteImageWithFakeCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake clipboard command requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	image := filepath.Join(dir, "clip.png")
+	if err := os.WriteFile(image, []byte("\x89PNG\r\n\x1a\nfake"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "fake-paste")
+	content := fmt.Sprintf("#!/bin/sh\ncat %q\n", image)
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tool, err := NewImagePaste(script)
+	if err != nil {
+		t.Fatalf("NewImagePaste() error: %v", err)
+	}
+	data, err := tool.PasteImage()
+	if err != nil {
+		t.Fatalf("PasteImage() error: %v", err)
+	}
+	if string(data) != "\x89PNG\r\n\x1a\nfake" {
+		t.Errorf("pasted data = %q", data)
+	}
+
+	// 复制工具不支持读取图片
+	if _, err := (&Tool{Name: "pbcopy", CopyArgs: []string{"pbcopy"}}).PasteImage(); err == nil {
+		t.Error("expected error for tool without paste support")
+	}
+}
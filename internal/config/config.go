@@ -6,6 +6,7 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
+	"qiniu-uploader/pkg/imaging"
 )
 
 type Config struct {
@@ -15,6 +16,46 @@ type Config struct {
 	QiniuBucket    string `mapstructure:"qiniu_bucket"`
 	QiniuDomain    string `mapstructure:"qiniu_domain"`
 
+	// 存储key配置，KeyTemplate 支持 {prefix} {name} {ext} {timestamp} {date} {year} {month} {day} {rand}
+	KeyPrefix   string `mapstructure:"key_prefix"`
+	KeyTemplate string `mapstructure:"key_template"`
+
+	// 命名profile，每个profile可覆盖账号、存储空间、域名和key规则
+	CurrentProfile string             `mapstructure:"current_profile"`
+	Profiles       map[string]Profile `mapstructure:"profiles"`
+
+	// Profile 当前生效的profile名称，为空表示使用顶层配置
+	Profile string `mapstructure:"-"`
+	// base 顶层的账号配置，切换和保存profile时使用
+	base Profile
+	// accessKey/secretKey 密钥的原始引用和解析结果
+	accessKey secretState
+	secretKey secretState
+	// envSecrets 被环境变量覆盖的顶层密钥，保存时写回配置文件中的原值
+	envSecrets map[string]envSecret
+
+	// project 当前目录或上级目录中的项目配置，shadow 保存被其覆盖的用户配置
+	project *projectConfig
+	shadow  map[string]interface{}
+	// file 读取的配置文件，inFile 为其中设置了的配置项
+	file   string
+	inFile map[string]bool
+	// v 加载该配置的viper实例，各配置互不影响，保存时在其基础上写入
+	v *viper.Viper
+
+	// 图片处理配置
+	StyleSeparator string `mapstructure:"style_separator"`
+	ThumbnailSize  string `mapstructure:"thumbnail_size"`
+	ThumbnailStyle string `mapstructure:"thumbnail_style"`
+
+	// 上传前预处理配置
+	ResizeMaxWidth  int    `mapstructure:"resize_max_width"`
+	ResizeMaxHeight int    `mapstructure:"resize_max_height"`
+	JPEGQuality     int    `mapstructure:"jpeg_quality"`
+	PNGOptimize     bool   `mapstructure:"png_optimize"`
+	StripMetadata   bool   `mapstructure:"strip_metadata"`
+	AutoConvert     string `mapstructure:"auto_convert"`
+
 	// 快捷键配置
 	HotkeyKeys  []int `mapstructure:"hotkey_keys"`
 	HotkeyCtrl  bool  `mapstructure:"hotkey_ctrl"`
@@ -22,10 +63,67 @@ type Config struct {
 	HotkeyAlt   bool  `mapstructure:"hotkey_alt"`
 
 	// UI配置
-	AutoCopyURL  bool `mapstructure:"auto_copy_url"`
-	ShowProgress bool `mapstructure:"show_progress"`
+	AutoCopyURL  bool   `mapstructure:"auto_copy_url"`
+	ShowProgress bool   `mapstructure:"show_progress"`
+	LinkFormat   string `mapstructure:"link_format"`
+
+	// ClipboardCommand 自定义剪贴板命令（文本通过stdin传入），为空时自动检测
+	ClipboardCommand string `mapstructure:"clipboard_command"`
+	// ClipboardPasteCommand 自定义读取剪贴板图片的命令（PNG输出到stdout），为空时自动检测
+	ClipboardPasteCommand string `mapstructure:"clipboard_paste_command"`
+
+	// HTTP服务配置
+	GinMode      string   `mapstructure:"gin_mode"`
+	Host         string   `mapstructure:"host"`
+	Port         int      `mapstructure:"port"`
+	MaxFileSize  int64    `mapstructure:"max_file_size"`
+	AllowedTypes []string `mapstructure:"allowed_types"`
+	CallbackURL  string   `mapstructure:"callback_url"`
+
+	// Listen 为 unix:<路径> 时监听Unix套接字，为 systemd 时使用套接字激活传入的监听，为空时监听 Host:Port
+	Listen string `mapstructure:"listen"`
+	// 超时时间（秒），0表示不限制
+	ReadTimeout     int `mapstructure:"read_timeout"`
+	WriteTimeout    int `mapstructure:"write_timeout"`
+	IdleTimeout     int `mapstructure:"idle_timeout"`
+	ShutdownTimeout int `mapstructure:"shutdown_timeout"`
+	MaxHeaderBytes  int `mapstructure:"max_header_bytes"`
+}
+
+// configFileName 配置文件名
+const configFileName = "config.yaml"
+
+// configFile 通过 --config 指定的配置文件，为空时使用配置目录下的 config.yaml
+var configFile string
+
+// envBindings 可直接覆盖配置项的环境变量
+var envBindings = []struct {
+	key string
+	env string
+}{
+	{"qiniu_access_key", "QINIU_ACCESS_KEY"},
+	{"qiniu_secret_key", "QINIU_SECRET_KEY"},
+	{"qiniu_bucket", "QINIU_BUCKET"},
+	{"qiniu_domain", "QINIU_DOMAIN"},
+	{"gin_mode", "GIN_MODE"},
+	{"host", "QINIU_UPLOADER_HOST"},
+	{"port", "QINIU_UPLOADER_PORT"},
+	{"max_file_size", "QINIU_UPLOADER_MAX_FILE_SIZE"},
+	{"allowed_types", "QINIU_UPLOADER_ALLOWED_TYPES"},
+	{"callback_url", "QINIU_UPLOADER_CALLBACK_URL"},
+	{"listen", "QINIU_UPLOADER_LISTEN"},
+	{"read_timeout", "QINIU_UPLOADER_READ_TIMEOUT"},
+	{"write_timeout", "QINIU_UPLOADER_WRITE_TIMEOUT"},
+	{"idle_timeout", "QINIU_UPLOADER_IDLE_TIMEOUT"},
+	{"shutdown_timeout", "QINIU_UPLOADER_SHUTDOWN_TIMEOUT"},
+}
+
+// SetFile 指定配置文件路径，需在 Load 之前调用
+func SetFile(path string) {
+	configFile = path
 }
 
+// Load 加载配置，密钥保留原始引用，需要连接七牛云时再调用 ResolveSecrets 解析
 func Load() (*Config, error) {
 	// 加载.env文件（如果存在）
 	_ = godotenv.Load()
@@ -36,32 +134,71 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(configDir)
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if configFile != "" {
+		v.SetConfigFile(configFile)
+	} else {
+		v.SetConfigName("config")
+		v.AddConfigPath(configDir)
+	}
 
 	// 设置默认值
-	setDefaults()
+	setDefaults(v)
 
 	// 读取配置文件
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		// 如果配置文件不存在，使用环境变量和默认值
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok && !os.IsNotExist(err) {
 			return nil, err
 		}
 	}
 
 	// 绑定环境变量
-	bindEnvVars()
+	bindEnvVars(v)
 
-	config := &Config{}
-	if err := viper.Unmarshal(config); err != nil {
+	config := &Config{v: v}
+	if err := v.Unmarshal(config); err != nil {
 		return nil, err
 	}
+	config.base = config.account()
+	config.file = v.ConfigFileUsed()
+	config.inFile = map[string]bool{}
+	for _, f := range Schema {
+		config.inFile[f.Key] = v.InConfig(f.Key)
+	}
+	config.envSecrets = loadEnvSecrets(config.file)
+
+	// 当前目录或上级目录中的 .qu.yaml 覆盖用户配置
+	if wd, err := os.Getwd(); err == nil {
+		if path := FindProjectFile(wd); path != "" {
+			if config.project, err = loadProject(path); err != nil {
+				return nil, err
+			}
+			config.applyProject()
+		}
+	}
 
 	return config, nil
 }
 
+// ImageOptions 根据配置生成上传前的图片预处理选项
+func (c *Config) ImageOptions() imaging.Options {
+	return imaging.Options{
+		MaxWidth:      c.ResizeMaxWidth,
+		MaxHeight:     c.ResizeMaxHeight,
+		Quality:       c.JPEGQuality,
+		OptimizePNG:   c.PNGOptimize,
+		StripMetadata: c.StripMetadata,
+		Convert:       c.AutoConvert,
+	}
+}
+
+// Dir 返回配置目录（默认 ~/.config/qu），历史记录等本地数据也保存在这里
+func Dir() (string, error) {
+	return getConfigDir()
+}
+
 // getConfigDir 获取配置目录
 func getConfigDir() (string, error) {
 	// 优先使用用户配置目录
@@ -77,61 +214,108 @@ func getConfigDir() (string, error) {
 
 	configDir := filepath.Join(homeDir, ".config", "qu")
 
-	// 确保配置目录存在
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	// 确保配置目录存在，配置中可能包含密钥，仅允许当前用户访问
+	if err := os.MkdirAll(configDir, 0700); err != nil {
 		return "", err
 	}
 
 	return configDir, nil
 }
 
-// setDefaults 设置默认配置
-func setDefaults() {
-	// 七牛云配置默认值
-	viper.SetDefault("qiniu_access_key", "")
-	viper.SetDefault("qiniu_secret_key", "")
-	viper.SetDefault("qiniu_bucket", "")
-	viper.SetDefault("qiniu_domain", "")
-
-	// 快捷键配置默认值 (Ctrl+Shift+U)
-	viper.SetDefault("hotkey_keys", []int{85}) // U键
-	viper.SetDefault("hotkey_ctrl", true)
-	viper.SetDefault("hotkey_shift", true)
-	viper.SetDefault("hotkey_alt", false)
-
-	// UI配置默认值
-	viper.SetDefault("auto_copy_url", true)
-	viper.SetDefault("show_progress", true)
+// setDefaults 设置默认配置，默认值定义在 Schema 中
+func setDefaults(v *viper.Viper) {
+	for _, f := range Schema {
+		v.SetDefault(f.Key, f.Default)
+	}
 }
 
 // bindEnvVars 绑定环境变量
-func bindEnvVars() {
-	viper.BindEnv("qiniu_access_key", "QINIU_ACCESS_KEY")
-	viper.BindEnv("qiniu_secret_key", "QINIU_SECRET_KEY")
-	viper.BindEnv("qiniu_bucket", "QINIU_BUCKET")
-	viper.BindEnv("qiniu_domain", "QINIU_DOMAIN")
+func bindEnvVars(v *viper.Viper) {
+	for _, b := range envBindings {
+		v.BindEnv(b.key, b.env)
+	}
 }
 
 // Save 保存配置
 func Save(cfg *Config) error {
-	configDir, err := getConfigDir()
+	configFile, err := FilePath()
 	if err != nil {
 		return err
 	}
 
+	// 项目配置的覆盖值不写入用户配置
+	cfg.restoreProject()
+	defer cfg.applyProject()
+
+	// 当前使用profile时，账号配置写入该profile，顶层保持不变
+	top := cfg.account()
+	profiles := make(map[string]Profile, len(cfg.Profiles)+1)
+	for name, p := range cfg.Profiles {
+		profiles[name] = p
+	}
+	if cfg.Profile != "" {
+		top = cfg.base
+		profiles[cfg.Profile] = cfg.account().diff(top)
+	}
+	cfg.Profiles = profiles
+	cfg.base = top
+
+	// 环境变量中的密钥只在运行时生效，不写入配置文件
+	top.QiniuAccessKey = cfg.persistedSecret("qiniu_access_key", top.QiniuAccessKey)
+	top.QiniuSecretKey = cfg.persistedSecret("qiniu_secret_key", top.QiniuSecretKey)
+
+	// 不是由 Load 读取的配置（如 Default）使用新的实例，只写入默认值和下面的配置项
+	v := cfg.v
+	if v == nil {
+		v = viper.New()
+		setDefaults(v)
+		cfg.v = v
+	}
+
 	// 设置配置值
-	viper.Set("qiniu_access_key", cfg.QiniuAccessKey)
-	viper.Set("qiniu_secret_key", cfg.QiniuSecretKey)
-	viper.Set("qiniu_bucket", cfg.QiniuBucket)
-	viper.Set("qiniu_domain", cfg.QiniuDomain)
-	viper.Set("hotkey_keys", cfg.HotkeyKeys)
-	viper.Set("hotkey_ctrl", cfg.HotkeyCtrl)
-	viper.Set("hotkey_shift", cfg.HotkeyShift)
-	viper.Set("hotkey_alt", cfg.HotkeyAlt)
-	viper.Set("auto_copy_url", cfg.AutoCopyURL)
-	viper.Set("show_progress", cfg.ShowProgress)
-
-	// 保存到文件
-	configFile := filepath.Join(configDir, "config.yaml")
-	return viper.WriteConfigAs(configFile)
-}
\ No newline at end of file
+	v.Set("qiniu_access_key", top.QiniuAccessKey)
+	v.Set("qiniu_secret_key", top.QiniuSecretKey)
+	v.Set("qiniu_bucket", top.QiniuBucket)
+	v.Set("qiniu_domain", top.QiniuDomain)
+	v.Set("key_prefix", top.KeyPrefix)
+	v.Set("key_template", top.KeyTemplate)
+	v.Set("current_profile", cfg.CurrentProfile)
+	v.Set("profiles", profilesToMap(profiles))
+	v.Set("style_separator", cfg.StyleSeparator)
+	v.Set("thumbnail_size", cfg.ThumbnailSize)
+	v.Set("thumbnail_style", cfg.ThumbnailStyle)
+	v.Set("resize_max_width", cfg.ResizeMaxWidth)
+	v.Set("resize_max_height", cfg.ResizeMaxHeight)
+	v.Set("jpeg_quality", cfg.JPEGQuality)
+	v.Set("png_optimize", cfg.PNGOptimize)
+	v.Set("strip_metadata", cfg.StripMetadata)
+	v.Set("auto_convert", cfg.AutoConvert)
+	v.Set("hotkey_keys", cfg.HotkeyKeys)
+	v.Set("hotkey_ctrl", cfg.HotkeyCtrl)
+	v.Set("hotkey_shift", cfg.HotkeyShift)
+	v.Set("hotkey_alt", cfg.HotkeyAlt)
+	v.Set("auto_copy_url", cfg.AutoCopyURL)
+	v.Set("show_progress", cfg.ShowProgress)
+	v.Set("link_format", cfg.LinkFormat)
+	v.Set("clipboard_command", cfg.ClipboardCommand)
+	v.Set("clipboard_paste_command", cfg.ClipboardPasteCommand)
+	v.Set("gin_mode", cfg.GinMode)
+	v.Set("host", cfg.Host)
+	v.Set("port", cfg.Port)
+	v.Set("max_file_size", cfg.MaxFileSize)
+	v.Set("allowed_types", cfg.AllowedTypes)
+	v.Set("callback_url", cfg.CallbackURL)
+	v.Set("listen", cfg.Listen)
+	v.Set("read_timeout", cfg.ReadTimeout)
+	v.Set("write_timeout", cfg.WriteTimeout)
+	v.Set("idle_timeout", cfg.IdleTimeout)
+	v.Set("shutdown_timeout", cfg.ShutdownTimeout)
+	v.Set("max_header_bytes", cfg.MaxHeaderBytes)
+
+	// 保存到文件，已存在的文件也收紧为 0600
+	v.SetConfigPermissions(0600)
+	if err := v.WriteConfigAs(configFile); err != nil {
+		return err
+	}
+	return os.Chmod(configFile, 0600)
+}
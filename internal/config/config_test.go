@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadDoesNotShareState(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("QINIU_UPLOADER_CONFIG_DIR", dir)
+	t.Setenv("QINIU_BUCKET", "")
+	t.Cleanup(func() { SetFile("") })
+
+	first := filepath.Join(dir, "first.yaml")
+	if err := os.WriteFile(first, []byte("qiniu_bucket: first\nunknown_key: kept\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	second := filepath.Join(dir, "second.yaml")
+	if err := os.WriteFile(second, []byte("qiniu_domain: cdn.example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	SetFile(first)
+	a, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	SetFile(second)
+	b, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if b.QiniuBucket != "" {
+		t.Errorf("QiniuBucket = %q, should not leak from the previous Load", b.QiniuBucket)
+	}
+
+	// 各自保存到自己的实例，不会写入另一个配置的值
+	SetFile(second)
+	if err := Save(b); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	data, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "first") || strings.Contains(string(data), "unknown_key") {
+		t.Errorf("second config should not contain values from the first:\n%s", data)
+	}
+
+	SetFile(first)
+	if err := Save(a); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	data, err = os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "unknown_key: kept") {
+		t.Errorf("first config should keep its own keys:\n%s", data)
+	}
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile 顶层配置对应的profile名称
+const DefaultProfile = "default"
+
+// Profile 命名账号/存储空间配置，未填写的字段继承顶层配置
+type Profile struct {
+	QiniuAccessKey string `mapstructure:"qiniu_access_key"`
+	QiniuSecretKey string `mapstructure:"qiniu_secret_key"`
+	QiniuBucket    string `mapstructure:"qiniu_bucket"`
+	QiniuDomain    string `mapstructure:"qiniu_domain"`
+	KeyPrefix      string `mapstructure:"key_prefix"`
+	KeyTemplate    string `mapstructure:"key_template"`
+}
+
+// StartupProfile 启动时默认使用的profile，依次取环境变量 QINIU_UPLOADER_PROFILE、项目配置中的 profile 和 current_profile
+func (c *Config) StartupProfile() string {
+	if name := os.Getenv("QINIU_UPLOADER_PROFILE"); name != "" {
+		return name
+	}
+	if c.project != nil && c.project.profile != "" {
+		return c.project.profile
+	}
+	return c.CurrentProfile
+}
+
+// UseProfile 切换当前生效的profile，name 为空或 default 时使用顶层配置。
+// 密钥保留原始引用，需要时再调用 ResolveSecrets 解析
+func (c *Config) UseProfile(name string) error {
+	// profile只覆盖用户配置，项目配置始终优先
+	c.restoreProject()
+	defer c.applyProject()
+
+	if name == "" || name == DefaultProfile {
+		c.applyAccount(c.base)
+		c.Profile = ""
+		return nil
+	}
+
+	p, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile不存在: %s（可用: %s）", name, strings.Join(c.ProfileNames(), ", "))
+	}
+
+	account := c.base
+	overlay(&account.QiniuAccessKey, p.QiniuAccessKey)
+	overlay(&account.QiniuSecretKey, p.QiniuSecretKey)
+	overlay(&account.QiniuBucket, p.QiniuBucket)
+	overlay(&account.QiniuDomain, p.QiniuDomain)
+	overlay(&account.KeyPrefix, p.KeyPrefix)
+	overlay(&account.KeyTemplate, p.KeyTemplate)
+	c.applyAccount(account)
+	c.Profile = name
+	return nil
+}
+
+// AddProfile 切换到指定profile，不存在时创建一个继承顶层配置的空profile
+func (c *Config) AddProfile(name string) error {
+	if name != "" && name != DefaultProfile {
+		if _, ok := c.Profiles[name]; !ok {
+			if c.Profiles == nil {
+				c.Profiles = map[string]Profile{}
+			}
+			c.Profiles[name] = Profile{}
+		}
+	}
+	return c.UseProfile(name)
+}
+
+// ProfileName 当前生效的profile名称
+func (c *Config) ProfileName() string {
+	if c.Profile == "" {
+		return DefaultProfile
+	}
+	return c.Profile
+}
+
+// ProfileNames 返回全部profile名称（含 default），按名称排序
+func (c *Config) ProfileNames() []string {
+	names := []string{DefaultProfile}
+	for name := range c.Profiles {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// ProfileAccount 返回指定profile合并顶层配置后的账号配置
+func (c *Config) ProfileAccount(name string) (Profile, error) {
+	tmp := *c
+	if err := tmp.UseProfile(name); err != nil {
+		return Profile{}, err
+	}
+	return tmp.account(), nil
+}
+
+// CopyProfiles 从已有配置复制profile信息，用于重新初始化时保留其他profile
+func (c *Config) CopyProfiles(from *Config) {
+	c.Profiles = from.Profiles
+	c.CurrentProfile = from.CurrentProfile
+	c.Profile = from.Profile
+	c.base = from.base
+	c.accessKey = from.accessKey
+	c.secretKey = from.secretKey
+	c.envSecrets = from.envSecrets
+}
+
+// StoredAccount 返回保存时写入配置文件的账号配置（密钥为原始引用或明文）
+func (c *Config) StoredAccount() Profile {
+	return c.account()
+}
+
+// account 当前生效的账号配置，密钥保留配置文件中的原始引用
+func (c *Config) account() Profile {
+	return Profile{
+		QiniuAccessKey: c.accessKey.raw(c.QiniuAccessKey),
+		QiniuSecretKey: c.secretKey.raw(c.QiniuSecretKey),
+		QiniuBucket:    c.QiniuBucket,
+		QiniuDomain:    c.QiniuDomain,
+		KeyPrefix:      c.KeyPrefix,
+		KeyTemplate:    c.KeyTemplate,
+	}
+}
+
+// applyAccount 将账号配置写入当前配置，密钥需再调用 ResolveSecrets 解析
+func (c *Config) applyAccount(p Profile) {
+	c.accessKey, c.secretKey = secretState{}, secretState{}
+	c.QiniuAccessKey = p.QiniuAccessKey
+	c.QiniuSecretKey = p.QiniuSecretKey
+	c.QiniuBucket = p.QiniuBucket
+	c.QiniuDomain = p.QiniuDomain
+	c.KeyPrefix = p.KeyPrefix
+	c.KeyTemplate = p.KeyTemplate
+}
+
+// overlay 非空时覆盖目标值
+func overlay(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+// diff 返回与 base 不同的字段，保存profile时只写入需要覆盖的值
+func (p Profile) diff(base Profile) Profile {
+	pick := func(value, baseValue string) string {
+		if value == baseValue {
+			return ""
+		}
+		return value
+	}
+	return Profile{
+		QiniuAccessKey: pick(p.QiniuAccessKey, base.QiniuAccessKey),
+		QiniuSecretKey: pick(p.QiniuSecretKey, base.QiniuSecretKey),
+		QiniuBucket:    pick(p.QiniuBucket, base.QiniuBucket),
+		QiniuDomain:    pick(p.QiniuDomain, base.QiniuDomain),
+		KeyPrefix:      pick(p.KeyPrefix, base.KeyPrefix),
+		KeyTemplate:    pick(p.KeyTemplate, base.KeyTemplate),
+	}
+}
+
+// toMap 转换为写入配置文件的map，省略空字段
+func (p Profile) toMap() map[string]interface{} {
+	m := map[string]interface{}{}
+	for key, value := range map[string]string{
+		"qiniu_access_key": p.QiniuAccessKey,
+		"qiniu_secret_key": p.QiniuSecretKey,
+		"qiniu_bucket":     p.QiniuBucket,
+		"qiniu_domain":     p.QiniuDomain,
+		"key_prefix":       p.KeyPrefix,
+		"key_template":     p.KeyTemplate,
+	} {
+		if value != "" {
+			m[key] = value
+		}
+	}
+	return m
+}
+
+// profilesToMap 转换全部profile为写入配置文件的map
+func profilesToMap(profiles map[string]Profile) map[string]interface{} {
+	m := make(map[string]interface{}, len(profiles))
+	for name, p := range profiles {
+		m[name] = p.toMap()
+	}
+	return m
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"testing"
+)
+
+func newProfileConfig() *Config {
+	cfg := &Config{
+		QiniuAccessKey: "ak-main",
+		QiniuSecretKey: "sk-main",
+		QiniuBucket:    "assets",
+		QiniuDomain:    "cdn.example.com",
+		KeyPrefix:      "images/",
+		KeyTemplate:    "{prefix}{timestamp}{ext}",
+		Profiles: map[string]Profile{
+			"blog": {QiniuBucket: "blog", QiniuDomain: "blog.example.com", KeyPrefix: "posts/"},
+			"work": {QiniuAccessKey: "ak-work", QiniuSecretKey: "sk-work", QiniuBucket: "docs"},
+		},
+	}
+	cfg.base = cfg.account()
+	return cfg
+}
+
+func TestUseProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		profile  string
+		expected Profile
+	}{
+		{"Default", "", Profile{"ak-main", "sk-main", "assets", "cdn.example.com", "images/", "{prefix}{timestamp}{ext}"}},
+		{"Explicit default", DefaultProfile, Profile{"ak-main", "sk-main", "assets", "cdn.example.com", "images/", "{prefix}{timestamp}{ext}"}},
+		{"Inherits account", "blog", Profile{"ak-main", "sk-main", "blog", "blog.example.com", "posts/", "{prefix}{timestamp}{ext}"}},
+		{"Separate account", "work", Profile{"ak-work", "sk-work", "docs", "cdn.example.com", "images/", "{prefix}{timestamp}{ext}"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newProfileConfig()
+			// 先切换到其他profile，确认切换时不会残留上一个profile的值
+			if err := cfg.UseProfile("work"); err != nil {
+				t.Fatal(err)
+			}
+			if err := cfg.UseProfile(tt.profile); err != nil {
+				t.Fatalf("UseProfile(%q) error: %v", tt.profile, err)
+			}
+			if got := cfg.account(); got != tt.expected {
+				t.Errorf("account = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+
+	cfg := newProfileConfig()
+	if err := cfg.UseProfile("missing"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+func TestProfileNames(t *testing.T) {
+	names := newProfileConfig().ProfileNames()
+	expected := []string{"default", "blog", "work"}
+	if len(names) != len(expected) {
+		t.Fatalf("ProfileNames() = %v, expected %v", names, expected)
+	}
+	for i := range names {
+		if names[i] != expected[i] {
+			t.Errorf("ProfileNames() = %v, expected %v", names, expected)
+			break
+		}
+	}
+}
+
+func TestProfileDiff(t *testing.T) {
+	cfg := newProfileConfig()
+	if err := cfg.UseProfile("blog"); err != nil {
+		t.Fatal(err)
+	}
+	cfg.QiniuDomain = "img.example.com"
+
+	got := cfg.account().diff(cfg.base)
+	expected := Profile{QiniuBucket: "blog", QiniuDomain: "img.example.com", KeyPrefix: "posts/"}
+	if got != expected {
+		t.Errorf("diff = %+v, expected %+v", got, expected)
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// ProjectFileName 项目配置文件名，从当前目录向上查找
+const ProjectFileName = ".qu.yaml"
+
+// projectProfileKey 项目配置中指定profile的配置项
+const projectProfileKey = "profile"
+
+// projectAliases 项目配置中配置项的别名
+var projectAliases = map[string]string{
+	"prefix": "key_prefix",
+}
+
+// projectConfig 项目配置，只能覆盖key规则、链接格式、profile和上传预处理，不能包含密钥
+type projectConfig struct {
+	path    string
+	profile string
+	values  map[string]interface{}
+}
+
+// FindProjectFile 从 dir 开始向上查找项目配置文件，未找到时返回空字符串
+func FindProjectFile(dir string) string {
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ProjectKeys 返回项目配置中可以设置的配置项
+func ProjectKeys() []string {
+	keys := []string{projectProfileKey}
+	for _, f := range Schema {
+		if f.Project {
+			keys = append(keys, f.Key)
+		}
+	}
+	return keys
+}
+
+// ValidateProjectFile 校验项目配置文件，包含密钥或无效取值时返回错误级别的问题
+func ValidateProjectFile(path string) ([]Issue, error) {
+	_, issues, err := readProject(path)
+	return issues, err
+}
+
+// loadProject 读取项目配置，存在错误时返回error，不支持的配置项被忽略并输出警告到stderr
+func loadProject(path string) (*projectConfig, error) {
+	project, issues, err := readProject(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		if !issue.Warning {
+			return nil, fmt.Errorf("项目配置 %s 无效: %s: %s", path, issue.Key, issue.Message)
+		}
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(os.Stderr, "警告: 项目配置 %s: %s: %s\n", path, issue.Key, issue.Message)
+	}
+	return project, nil
+}
+
+// readProject 读取并校验项目配置
+func readProject(path string) (*projectConfig, []Issue, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, nil, fmt.Errorf("读取项目配置失败: %v", err)
+	}
+
+	project := &projectConfig{path: path, values: map[string]interface{}{}}
+	var issues []Issue
+	settings := v.AllSettings()
+	for key, raw := range settings {
+		if key == projectProfileKey {
+			name, err := cast.ToStringE(raw)
+			if err != nil {
+				issues = append(issues, Issue{Key: key, Message: "profile 需要字符串"})
+				continue
+			}
+			project.profile = name
+			continue
+		}
+
+		f, ok := LookupField(key)
+		if target, alias := projectAliases[key]; alias {
+			if _, dup := settings[target]; dup {
+				issues = append(issues, Issue{Key: key, Message: "与 " + target + " 重复，已忽略", Warning: true})
+				continue
+			}
+			f, ok = LookupField(target)
+		}
+		switch {
+		case ok && f.Secret:
+			issues = append(issues, Issue{Key: key, Message: "项目配置不能包含密钥，请移到用户配置或使用 env: 引用"})
+			continue
+		case !ok || !f.Project:
+			issues = append(issues, Issue{Key: key, Message: "项目配置不支持该配置项，已忽略（可设置: " + strings.Join(ProjectKeys(), ", ") + "）", Warning: true})
+			continue
+		}
+
+		value, err := FormatValue(f, raw)
+		if err == nil {
+			raw, err = f.Parse(value)
+		}
+		if err != nil {
+			issues = append(issues, Issue{Key: key, Message: err.Error()})
+			continue
+		}
+		project.values[f.Key] = raw
+	}
+
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Key < issues[j].Key })
+	return project, issues, nil
+}
+
+// ProjectFile 返回生效的项目配置文件路径，未使用时为空字符串
+func (c *Config) ProjectFile() string {
+	if c.project == nil {
+		return ""
+	}
+	return c.project.path
+}
+
+// ProjectOverrides 判断配置项是否被项目配置覆盖
+func (c *Config) ProjectOverrides(key string) bool {
+	_, ok := c.shadow[key]
+	return ok
+}
+
+// applyProject 用项目配置覆盖当前配置，被覆盖的用户配置保存在 shadow 中
+func (c *Config) applyProject() {
+	if c.project == nil {
+		return
+	}
+	shadow := make(map[string]interface{}, len(c.project.values))
+	v := reflect.ValueOf(c).Elem()
+	for key, value := range c.project.values {
+		field, err := fieldByKey(v, key)
+		if err != nil {
+			continue
+		}
+		shadow[key] = field.Interface()
+		field.Set(reflect.ValueOf(value))
+	}
+	c.shadow = shadow
+}
+
+// restoreProject 恢复被项目配置覆盖的用户配置
+func (c *Config) restoreProject() {
+	v := reflect.ValueOf(c).Elem()
+	for key, value := range c.shadow {
+		if field, err := fieldByKey(v, key); err == nil {
+			field.Set(reflect.ValueOf(value))
+		}
+	}
+	c.shadow = nil
+}
+
+// assign 设置配置项，被项目配置覆盖时只修改用户配置中的值
+func (c *Config) assign(key string, value reflect.Value) error {
+	if old, ok := c.shadow[key]; ok {
+		c.shadow[key] = value.Convert(reflect.TypeOf(old)).Interface()
+		return nil
+	}
+	field, err := fieldByKey(reflect.ValueOf(c).Elem(), key)
+	if err != nil {
+		return err
+	}
+	// 配置项类型与字段类型不同时转换，如 int 转为 int64
+	field.Set(value.Convert(field.Type()))
+	return nil
+}
+
+// Source 返回配置项当前生效值的来源：项目配置、profile、环境变量、配置文件或默认值
+func (c *Config) Source(key string) string {
+	f, ok := LookupField(key)
+	if !ok {
+		return ""
+	}
+	if c.ProjectOverrides(f.Key) {
+		return c.project.path
+	}
+	if f.Account && c.Profile != "" {
+		if field, err := fieldByKey(reflect.ValueOf(c.Profiles[c.Profile]), f.Key); err == nil && field.String() != "" {
+			return fmt.Sprintf("%s (profile %s)", c.file, c.Profile)
+		}
+	}
+	for _, b := range envBindings {
+		if b.key == f.Key && os.Getenv(b.env) != "" {
+			return "环境变量 " + b.env
+		}
+	}
+	if c.inFile[f.Key] {
+		return c.file
+	}
+	return "默认值"
+}
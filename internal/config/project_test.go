@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeProject 在 dir 中写入项目配置
+func writeProject(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, ProjectFileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFindProjectFile(t *testing.T) {
+	root := t.TempDir()
+	deep := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeProject(t, filepath.Join(root, "a"), "key_prefix: docs/\n")
+	if got := FindProjectFile(deep); got != path {
+		t.Errorf("FindProjectFile() = %q, expected %q", got, path)
+	}
+	if got := FindProjectFile(filepath.Join(root, "a")); got != path {
+		t.Errorf("FindProjectFile() = %q, expected %q", got, path)
+	}
+}
+
+func TestProjectOverrides(t *testing.T) {
+	path := writeProject(t, t.TempDir(), `key_prefix: docs/
+link_format: markdown
+jpeg_quality: 80
+profile: blog
+qiniu_bucket: ignored
+`)
+	project, err := loadProject(path)
+	if err != nil {
+		t.Fatalf("loadProject() error: %v", err)
+	}
+
+	cfg := newProfileConfig()
+	cfg.LinkFormat = "url"
+	cfg.project = project
+	cfg.applyProject()
+
+	if cfg.StartupProfile() != "blog" {
+		t.Errorf("StartupProfile() = %q, expected blog", cfg.StartupProfile())
+	}
+	if err := cfg.UseProfile("blog"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 项目配置优先于profile
+	if cfg.KeyPrefix != "docs/" || cfg.QiniuBucket != "blog" || cfg.LinkFormat != "markdown" || cfg.JPEGQuality != 80 {
+		t.Errorf("unexpected merged config: prefix=%q bucket=%q format=%q quality=%d",
+			cfg.KeyPrefix, cfg.QiniuBucket, cfg.LinkFormat, cfg.JPEGQuality)
+	}
+	if got := cfg.Source("key_prefix"); got != path {
+		t.Errorf("Source(key_prefix) = %q, expected %q", got, path)
+	}
+
+	// 修改被覆盖的配置项只影响用户配置
+	if err := cfg.Set("link_format", "html"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LinkFormat != "markdown" {
+		t.Errorf("LinkFormat = %q, project value should stay in effect", cfg.LinkFormat)
+	}
+
+	cfg.restoreProject()
+	if cfg.KeyPrefix != "posts/" || cfg.LinkFormat != "html" || cfg.JPEGQuality != 0 {
+		t.Errorf("restoreProject() prefix=%q format=%q quality=%d, expected user values",
+			cfg.KeyPrefix, cfg.LinkFormat, cfg.JPEGQuality)
+	}
+}
+
+func TestProjectRejectsSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"Allowed keys", "key_template: \"{prefix}{date}/{name}{ext}\"\nstrip_metadata: false\n", false},
+		{"Unsupported key ignored", "qiniu_domain: cdn.example.com\n", false},
+		{"Secret key", "qiniu_secret_key: sk\n", true},
+		{"Invalid value", "jpeg_quality: 150\n", true},
+		{"Invalid alias value", "prefix: [a, b]\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeProject(t, t.TempDir(), tt.content)
+			if _, err := loadProject(path); (err != nil) != tt.wantErr {
+				t.Errorf("loadProject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProjectPrefixAlias(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		warnings int
+	}{
+		{"Alias", "prefix: docs/\n", "docs/", 0},
+		{"Canonical key wins", "prefix: docs/\nkey_prefix: blog/\n", "blog/", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeProject(t, t.TempDir(), tt.content)
+			project, issues, err := readProject(path)
+			if err != nil {
+				t.Fatalf("readProject() error: %v", err)
+			}
+			if got := project.values["key_prefix"]; got != tt.expected {
+				t.Errorf("key_prefix = %v, expected %q", got, tt.expected)
+			}
+			if len(issues) != tt.warnings {
+				t.Errorf("issues = %+v, expected %d warnings", issues, tt.warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,486 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	"qiniu-uploader/pkg/imaging"
+	"qiniu-uploader/pkg/qiniu"
+)
+
+// FieldType 配置项类型
+type FieldType string
+
+// 配置项类型
+const (
+	TypeString     FieldType = "string"
+	TypeInt        FieldType = "int"
+	TypeBool       FieldType = "bool"
+	TypeIntList    FieldType = "int[]"
+	TypeStringList FieldType = "string[]"
+)
+
+// Field 配置项定义
+type Field struct {
+	Key         string
+	Type        FieldType
+	Default     interface{}
+	Description string
+
+	// Allowed 可选值，为空表示不限制
+	Allowed []string
+	// Min/Max 整数取值范围，Max 为0表示不限制上限
+	Min, Max int
+	// Account 为true表示可以按profile覆盖
+	Account bool
+	// Secret 为true表示是密钥，可使用 env:/cmd:/keyring:/file: 引用
+	Secret bool
+	// Project 为true表示可以在项目配置 .qu.yaml 中覆盖
+	Project bool
+
+	check func(value string) error
+}
+
+// bucketPattern 七牛存储空间名称规则
+var bucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$`)
+
+// Schema 全部配置项，顺序即 config.yaml 中的推荐顺序
+var Schema = []Field{
+	// 七牛云配置
+	{Key: "qiniu_access_key", Type: TypeString, Default: "", Description: "七牛云 Access Key", Account: true, Secret: true},
+	{Key: "qiniu_secret_key", Type: TypeString, Default: "", Description: "七牛云 Secret Key", Account: true, Secret: true},
+	{Key: "qiniu_bucket", Type: TypeString, Default: "", Description: "存储空间名称", Account: true, check: checkBucket},
+	{Key: "qiniu_domain", Type: TypeString, Default: "", Description: "访问域名（不含 https://）", Account: true, check: checkDomain},
+
+	// 存储key默认为 images/<纳秒时间戳><扩展名>
+	{Key: "key_prefix", Type: TypeString, Default: qiniu.DefaultKeyPrefix, Description: "自动生成key的前缀", Account: true, Project: true},
+	{Key: "key_template", Type: TypeString, Default: qiniu.DefaultKeyTemplate, Description: "自动生成key的模板", Account: true, check: qiniu.ValidateKeyTemplate, Project: true},
+	{Key: "current_profile", Type: TypeString, Default: "", Description: "默认使用的profile"},
+
+	// 图片处理配置
+	{Key: "style_separator", Type: TypeString, Default: "-", Description: "图片样式分隔符", Allowed: []string{"-", "_", "!", "/", "~"}},
+	{Key: "thumbnail_size", Type: TypeString, Default: "300x300", Description: "缩略图尺寸", check: checkImageSize},
+	{Key: "thumbnail_style", Type: TypeString, Default: "", Description: "缩略图样式名，设置后优先于 thumbnail_size"},
+
+	// 上传前预处理默认关闭
+	{Key: "resize_max_width", Type: TypeInt, Default: 0, Description: "上传前缩小到的最大宽度，0表示不限制", Project: true},
+	{Key: "resize_max_height", Type: TypeInt, Default: 0, Description: "上传前缩小到的最大高度，0表示不限制", Project: true},
+	{Key: "jpeg_quality", Type: TypeInt, Default: 0, Description: "JPEG重新压缩质量，0表示不压缩", Max: 100, Project: true},
+	{Key: "png_optimize", Type: TypeBool, Default: false, Description: "以最高压缩级别重新编码PNG", Project: true},
+
+	// 默认移除EXIF/GPS等元数据，保护隐私
+	{Key: "strip_metadata", Type: TypeBool, Default: true, Description: "上传前移除EXIF、XMP、IPTC等元数据", Project: true},
+
+	// 本地格式转换默认关闭，可选 webp、avif
+	{Key: "auto_convert", Type: TypeString, Default: "", Description: "上传前转换格式: webp、avif", check: checkConvert, Project: true},
+
+	// 快捷键配置默认值 (Ctrl+Shift+U)
+	{Key: "hotkey_keys", Type: TypeIntList, Default: []int{85}, Description: "快捷键键码"},
+	{Key: "hotkey_ctrl", Type: TypeBool, Default: true, Description: "快捷键包含 Ctrl"},
+	{Key: "hotkey_shift", Type: TypeBool, Default: true, Description: "快捷键包含 Shift"},
+	{Key: "hotkey_alt", Type: TypeBool, Default: false, Description: "快捷键包含 Alt"},
+
+	// UI配置默认值
+	{Key: "auto_copy_url", Type: TypeBool, Default: true, Description: "上传后自动复制链接"},
+	{Key: "show_progress", Type: TypeBool, Default: true, Description: "显示上传进度"},
+	{Key: "link_format", Type: TypeString, Default: "url", Description: "链接格式: url|markdown|html|bbcode|json 或Go模板", check: checkLinkFormat, Project: true},
+	{Key: "clipboard_command", Type: TypeString, Default: "", Description: "自定义剪贴板命令"},
+	{Key: "clipboard_paste_command", Type: TypeString, Default: "", Description: "自定义读取剪贴板图片的命令"},
+
+	// HTTP服务默认只监听本机
+	{Key: "gin_mode", Type: TypeString, Default: "release", Description: "HTTP服务运行模式", Allowed: []string{"debug", "release", "test"}},
+	{Key: "host", Type: TypeString, Default: "127.0.0.1", Description: "HTTP服务监听地址"},
+	{Key: "port", Type: TypeInt, Default: 8080, Description: "HTTP服务端口", Max: 65535},
+	{Key: "listen", Type: TypeString, Default: "", Description: "HTTP服务监听方式: unix:<路径> 或 systemd，为空时监听 host:port", check: checkListen},
+	{Key: "read_timeout", Type: TypeInt, Default: 600, Description: "读取请求（含上传内容）的超时时间（秒），0表示不限制"},
+	{Key: "write_timeout", Type: TypeInt, Default: 900, Description: "处理请求并写出响应的超时时间（秒），0表示不限制"},
+	{Key: "idle_timeout", Type: TypeInt, Default: 120, Description: "空闲连接的保持时间（秒），0表示使用 read_timeout"},
+	{Key: "shutdown_timeout", Type: TypeInt, Default: 30, Description: "退出时等待进行中请求完成的时间（秒）"},
+	{Key: "max_header_bytes", Type: TypeInt, Default: 1 << 20, Description: "请求头大小上限（字节）", Min: 4096},
+	{Key: "max_file_size", Type: TypeInt, Default: 10 * 1024 * 1024, Description: "HTTP上传文件大小上限（字节）", Min: 1},
+	{Key: "allowed_types", Type: TypeStringList, Default: []string{"image/*"}, Description: "HTTP上传允许的MIME类型，支持 image/* 通配"},
+	{Key: "callback_url", Type: TypeString, Default: "", Description: "七牛云上传回调地址，如 https://example.com/api/callback/qiniu", check: checkCallbackURL},
+}
+
+// LookupField 查找配置项
+func LookupField(key string) (*Field, bool) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	for i := range Schema {
+		if Schema[i].Key == key {
+			return &Schema[i], true
+		}
+	}
+	return nil, false
+}
+
+// FieldKeys 返回全部配置项名称
+func FieldKeys() []string {
+	keys := make([]string, len(Schema))
+	for i, f := range Schema {
+		keys[i] = f.Key
+	}
+	return keys
+}
+
+// Parse 解析并校验字符串形式的配置值
+func (f *Field) Parse(value string) (interface{}, error) {
+	value = strings.TrimSpace(value)
+
+	var parsed interface{}
+	switch f.Type {
+	case TypeString:
+		parsed = value
+	case TypeInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s 需要整数: %s", f.Key, value)
+		}
+		if n < f.Min || (f.Max > 0 && n > f.Max) {
+			if f.Max > 0 {
+				return nil, fmt.Errorf("%s 取值范围为 %d-%d: %d", f.Key, f.Min, f.Max, n)
+			}
+			return nil, fmt.Errorf("%s 不能小于 %d: %d", f.Key, f.Min, n)
+		}
+		parsed = n
+	case TypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s 需要布尔值 (true/false): %s", f.Key, value)
+		}
+		parsed = b
+	case TypeIntList:
+		var list []int
+		for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("%s 需要以逗号分隔的整数: %s", f.Key, value)
+			}
+			list = append(list, n)
+		}
+		parsed = list
+	case TypeStringList:
+		list := []string{}
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				list = append(list, part)
+			}
+		}
+		parsed = list
+	}
+
+	if len(f.Allowed) > 0 && !containsString(f.Allowed, value) {
+		return nil, fmt.Errorf("%s 的可选值为 %s: %s", f.Key, strings.Join(quoteAll(f.Allowed), ", "), value)
+	}
+	if f.check != nil && value != "" {
+		if err := f.check(value); err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Key, err)
+		}
+	}
+	return parsed, nil
+}
+
+// Validate 校验配置文件中读取的原始值
+func (f *Field) Validate(raw interface{}) error {
+	if raw == nil {
+		return nil
+	}
+	value, err := FormatValue(f, raw)
+	if err != nil {
+		return err
+	}
+	_, err = f.Parse(value)
+	return err
+}
+
+// FormatValue 将配置值格式化为字符串，类型不匹配时返回错误
+func FormatValue(f *Field, raw interface{}) (string, error) {
+	switch f.Type {
+	case TypeString:
+		switch raw.(type) {
+		case map[string]interface{}, []interface{}:
+			return "", fmt.Errorf("%s 需要字符串", f.Key)
+		}
+		return cast.ToStringE(raw)
+	case TypeInt:
+		n, err := cast.ToIntE(raw)
+		if err != nil {
+			return "", fmt.Errorf("%s 需要整数: %v", f.Key, raw)
+		}
+		return strconv.Itoa(n), nil
+	case TypeBool:
+		b, err := cast.ToBoolE(raw)
+		if err != nil {
+			return "", fmt.Errorf("%s 需要布尔值: %v", f.Key, raw)
+		}
+		return strconv.FormatBool(b), nil
+	case TypeIntList:
+		list, err := cast.ToIntSliceE(raw)
+		if err != nil {
+			return "", fmt.Errorf("%s 需要整数列表: %v", f.Key, raw)
+		}
+		parts := make([]string, len(list))
+		for i, n := range list {
+			parts[i] = strconv.Itoa(n)
+		}
+		return strings.Join(parts, ","), nil
+	case TypeStringList:
+		list, err := cast.ToStringSliceE(raw)
+		if err != nil {
+			return "", fmt.Errorf("%s 需要字符串列表: %v", f.Key, raw)
+		}
+		return strings.Join(list, ","), nil
+	}
+	return "", fmt.Errorf("未知的配置类型: %s", f.Type)
+}
+
+// Get 读取配置项的值，密钥返回配置文件中的原始引用
+func (c *Config) Get(key string) (string, error) {
+	f, ok := LookupField(key)
+	if !ok {
+		return "", unknownKeyError(key)
+	}
+	if f.Secret {
+		stored := c.StoredAccount()
+		if f.Key == "qiniu_access_key" {
+			return stored.QiniuAccessKey, nil
+		}
+		return stored.QiniuSecretKey, nil
+	}
+
+	field, err := fieldByKey(reflect.ValueOf(c).Elem(), f.Key)
+	if err != nil {
+		return "", err
+	}
+	return FormatValue(f, field.Interface())
+}
+
+// Set 校验并设置配置项，使用profile时账号相关的配置写入当前profile，
+// 被项目配置覆盖的配置项只修改用户配置中的值
+func (c *Config) Set(key, value string) error {
+	f, ok := LookupField(key)
+	if !ok {
+		return unknownKeyError(key)
+	}
+	parsed, err := f.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	// 显式设置的顶层密钥按新值保存
+	if f.Secret && c.Profile == "" {
+		delete(c.envSecrets, f.Key)
+	}
+	return c.assign(f.Key, reflect.ValueOf(parsed))
+}
+
+// Unset 恢复配置项的默认值，使用profile时账号相关的配置恢复为继承顶层配置
+func (c *Config) Unset(key string) error {
+	f, ok := LookupField(key)
+	if !ok {
+		return unknownKeyError(key)
+	}
+
+	if f.Account && c.Profile != "" {
+		inherited, err := fieldByKey(reflect.ValueOf(&c.base).Elem(), f.Key)
+		if err != nil {
+			return err
+		}
+		return c.assign(f.Key, inherited)
+	}
+	return c.assign(f.Key, reflect.ValueOf(f.Default))
+}
+
+// fieldByKey 按 mapstructure 标签查找结构体字段
+func fieldByKey(v reflect.Value, key string) (reflect.Value, error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("mapstructure"); tag == key {
+			return v.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("配置项 %s 不支持此操作", key)
+}
+
+// unknownKeyError 未知配置项错误
+func unknownKeyError(key string) error {
+	return fmt.Errorf("未知的配置项: %s（运行 'qu config set --help' 查看全部配置项）", key)
+}
+
+// checkBucket 校验存储空间名称
+func checkBucket(value string) error {
+	if !bucketPattern.MatchString(value) {
+		return fmt.Errorf("存储空间名称只能包含小写字母、数字和-，长度3-63: %s", value)
+	}
+	return nil
+}
+
+// checkDomain 校验访问域名
+func checkDomain(value string) error {
+	if strings.Contains(value, "://") || strings.ContainsAny(value, " /") {
+		return fmt.Errorf("域名不应包含协议或路径，如 cdn.example.com: %s", value)
+	}
+	return nil
+}
+
+// checkCallbackURL 校验上传回调地址，需要七牛云可以访问的 http(s) 地址
+func checkCallbackURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("回调地址需要以 http:// 或 https:// 开头: %s", value)
+	}
+	return nil
+}
+
+// checkListen 校验HTTP服务监听方式
+func checkListen(value string) error {
+	if value == "systemd" || (strings.HasPrefix(value, "unix:") && len(value) > len("unix:")) {
+		return nil
+	}
+	return fmt.Errorf("监听方式需要为 unix:<路径> 或 systemd: %s", value)
+}
+
+// checkImageSize 校验缩略图尺寸
+func checkImageSize(value string) error {
+	_, _, err := qiniu.ParseImageSize(value)
+	return err
+}
+
+// checkConvert 校验转换格式
+func checkConvert(value string) error {
+	_, err := imaging.ParseConvertFormat(value)
+	return err
+}
+
+// checkLinkFormat 校验链接格式，内置格式或Go模板
+func checkLinkFormat(value string) error {
+	switch strings.ToLower(value) {
+	case "url", "markdown", "html", "bbcode", "json":
+		return nil
+	}
+	if !strings.Contains(value, "{{") {
+		return fmt.Errorf("未知的链接格式: %s（可选 url|markdown|html|bbcode|json 或Go模板）", value)
+	}
+	if _, err := template.New("link").Parse(value); err != nil {
+		return fmt.Errorf("链接模板解析失败: %v", err)
+	}
+	return nil
+}
+
+// containsString 判断列表中是否包含指定值
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteAll 为空字符串添加引号，便于显示
+func quoteAll(list []string) []string {
+	out := make([]string, len(list))
+	for i, v := range list {
+		if v == "" {
+			v = `""`
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// Issue 配置校验发现的问题
+type Issue struct {
+	Key     string
+	Message string
+	// Warning 为true表示不影响使用的警告
+	Warning bool
+}
+
+// FilePath 返回配置文件路径，通过 SetFile 指定时使用指定的文件
+func FilePath() (string, error) {
+	if configFile != "" {
+		return configFile, nil
+	}
+	dir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// ValidateFile 按 Schema 校验配置文件中的类型、取值和profile
+func ValidateFile(path string) ([]Issue, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var issues []Issue
+	settings := v.AllSettings()
+	for key, raw := range settings {
+		if key == "profiles" {
+			continue
+		}
+		f, ok := LookupField(key)
+		if !ok {
+			issues = append(issues, Issue{Key: key, Message: "未知的配置项", Warning: true})
+			continue
+		}
+		if err := f.Validate(raw); err != nil {
+			issues = append(issues, Issue{Key: key, Message: err.Error()})
+		}
+	}
+
+	profiles, _ := settings["profiles"].(map[string]interface{})
+	if raw, ok := settings["profiles"]; ok && profiles == nil && raw != nil {
+		issues = append(issues, Issue{Key: "profiles", Message: "profiles 需要是以profile名称为键的映射"})
+	}
+	for name, raw := range profiles {
+		values, ok := raw.(map[string]interface{})
+		if !ok {
+			issues = append(issues, Issue{Key: "profiles." + name, Message: "profile 需要是映射"})
+			continue
+		}
+		for key, value := range values {
+			full := "profiles." + name + "." + key
+			f, ok := LookupField(key)
+			if !ok || !f.Account {
+				issues = append(issues, Issue{Key: full, Message: "profile 中只能设置账号相关的配置项"})
+				continue
+			}
+			if err := f.Validate(value); err != nil {
+				issues = append(issues, Issue{Key: full, Message: err.Error()})
+			}
+		}
+	}
+
+	if current := v.GetString("current_profile"); current != "" && current != DefaultProfile {
+		if _, ok := profiles[current]; !ok {
+			issues = append(issues, Issue{Key: "current_profile", Message: "profile不存在: " + current})
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Key < issues[j].Key })
+	return issues, nil
+}
+
+// Default 返回全部配置项为默认值的配置
+func Default() *Config {
+	cfg := &Config{}
+	for _, f := range Schema {
+		_ = cfg.assign(f.Key, reflect.ValueOf(f.Default))
+	}
+	cfg.base = cfg.account()
+	return cfg
+}
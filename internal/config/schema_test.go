@@ -0,0 +1,168 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFieldParse(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{"qiniu_bucket", "my-bucket", false},
+		{"qiniu_bucket", "My_Bucket", true},
+		{"qiniu_domain", "cdn.example.com", false},
+		{"qiniu_domain", "https://cdn.example.com", true},
+		{"key_template", "{prefix}{date}/{name}{ext}", false},
+		{"key_template", "{prefix}{hash}", true},
+		{"style_separator", "!", false},
+		{"style_separator", "#", true},
+		{"thumbnail_size", "200x", false},
+		{"thumbnail_size", "big", true},
+		{"jpeg_quality", "85", false},
+		{"jpeg_quality", "101", true},
+		{"resize_max_width", "-1", true},
+		{"resize_max_width", "wide", true},
+		{"strip_metadata", "false", false},
+		{"strip_metadata", "maybe", true},
+		{"auto_convert", "webp", false},
+		{"auto_convert", "gif", true},
+		{"hotkey_keys", "85, 86", false},
+		{"hotkey_keys", "U", true},
+		{"link_format", "markdown", false},
+		{"link_format", "<a href=\"{{.URL}}\">{{.Alt}}</a>", false},
+		{"link_format", "rst", true},
+		{"clipboard_command", "xclip -selection clipboard", false},
+		{"listen", "unix:/run/qu/qu.sock", false},
+		{"listen", "systemd", false},
+		{"listen", "unix:", true},
+		{"listen", "0.0.0.0:8080", true},
+		{"max_header_bytes", "1024", true},
+	}
+
+	for _, tt := range tests {
+		f, ok := LookupField(tt.key)
+		if !ok {
+			t.Fatalf("LookupField(%q) not found", tt.key)
+		}
+		if _, err := f.Parse(tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%s=%q) error = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestConfigSetGetUnset(t *testing.T) {
+	cfg := &Config{LinkFormat: "url", JPEGQuality: 0}
+
+	if err := cfg.Set("jpeg_quality", "85"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if err := cfg.Set("link_format", "{{.URL}} ({{.Size}} bytes)"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if err := cfg.Set("hotkey_keys", "85,86"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if cfg.JPEGQuality != 85 || cfg.LinkFormat != "{{.URL}} ({{.Size}} bytes)" || len(cfg.HotkeyKeys) != 2 {
+		t.Errorf("Set() did not update fields: %+v", cfg)
+	}
+
+	if got, _ := cfg.Get("hotkey_keys"); got != "85,86" {
+		t.Errorf("Get(hotkey_keys) = %q", got)
+	}
+	if err := cfg.Set("unknown_key", "x"); err == nil {
+		t.Error("expected error for unknown key")
+	}
+	if err := cfg.Set("jpeg_quality", "high"); err == nil {
+		t.Error("expected error for invalid value")
+	}
+
+	if err := cfg.Unset("link_format"); err != nil {
+		t.Fatalf("Unset() error: %v", err)
+	}
+	if cfg.LinkFormat != "url" {
+		t.Errorf("Unset(link_format) = %q, expected default", cfg.LinkFormat)
+	}
+}
+
+func TestUnsetInheritsInProfile(t *testing.T) {
+	cfg := newProfileConfig()
+	if err := cfg.UseProfile("blog"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Unset("qiniu_domain"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.QiniuDomain != "cdn.example.com" {
+		t.Errorf("Unset in profile = %q, expected inherited value", cfg.QiniuDomain)
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `qiniu_bucket: Bad_Bucket
+jpeg_quality: "high"
+strip_metadata: true
+unknown_option: 1
+current_profile: missing
+profiles:
+  blog:
+    qiniu_bucket: blog
+    link_format: markdown
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := ValidateFile(path)
+	if err != nil {
+		t.Fatalf("ValidateFile() error: %v", err)
+	}
+
+	expected := map[string]bool{
+		"current_profile":           false,
+		"jpeg_quality":              false,
+		"profiles.blog.link_format": false,
+		"qiniu_bucket":              false,
+		"unknown_option":            true,
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("ValidateFile() = %+v, expected %d issues", issues, len(expected))
+	}
+	for _, issue := range issues {
+		warning, ok := expected[issue.Key]
+		if !ok {
+			t.Errorf("unexpected issue: %+v", issue)
+			continue
+		}
+		if issue.Warning != warning {
+			t.Errorf("issue %s warning = %v, expected %v", issue.Key, issue.Warning, warning)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	cfg := Default()
+	if cfg.Port != 8080 || cfg.Host != "127.0.0.1" || cfg.GinMode != "release" {
+		t.Errorf("unexpected server defaults: %s:%d %s", cfg.Host, cfg.Port, cfg.GinMode)
+	}
+	if cfg.MaxFileSize != 10*1024*1024 || len(cfg.AllowedTypes) != 1 || cfg.AllowedTypes[0] != "image/*" {
+		t.Errorf("unexpected upload limits: %d %v", cfg.MaxFileSize, cfg.AllowedTypes)
+	}
+	if cfg.KeyPrefix != "images/" || !cfg.StripMetadata || cfg.LinkFormat != "url" {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+
+	if err := cfg.Set("allowed_types", "image/png, image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cfg.Get("allowed_types"); got != "image/png,image/jpeg" {
+		t.Errorf("Get(allowed_types) = %q", got)
+	}
+	if err := cfg.Set("max_file_size", "1024"); err != nil || cfg.MaxFileSize != 1024 {
+		t.Errorf("Set(max_file_size) = %d, %v", cfg.MaxFileSize, err)
+	}
+}
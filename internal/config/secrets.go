@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/spf13/viper"
+	"qiniu-uploader/internal/secret"
+)
+
+// SecretsFileName 加密密钥文件名，位于配置目录下
+const SecretsFileName = "secrets.enc"
+
+var (
+	resolverOnce sync.Once
+	resolver     *secret.Resolver
+)
+
+// Secrets 返回密钥解析器，用于读取和保存 env:、cmd:、keyring:、file: 引用
+func Secrets() *secret.Resolver {
+	resolverOnce.Do(func() {
+		path := SecretsFileName
+		if dir, err := getConfigDir(); err == nil {
+			path = filepath.Join(dir, SecretsFileName)
+		}
+		resolver = secret.NewResolver(path)
+	})
+	return resolver
+}
+
+// secretState 解析后的密钥及其在配置文件中的原始值
+type secretState struct {
+	ref   string
+	value string
+	env   string
+	err   error
+}
+
+// SecretInfo 密钥来源信息
+type SecretInfo struct {
+	// Source 来源描述，如"系统密钥环 (default/secret_key)"
+	Source string
+	// Err 解析失败的原因
+	Err error
+}
+
+// ResolveSecrets 解析 Access Key 和 Secret Key 中的引用，失败时对应字段置空，
+// 原因可通过 CredentialSources 查看
+func (c *Config) ResolveSecrets() error {
+	c.accessKey = resolveSecret(c.accessKey.raw(c.QiniuAccessKey), "QINIU_ACCESS_KEY")
+	c.secretKey = resolveSecret(c.secretKey.raw(c.QiniuSecretKey), "QINIU_SECRET_KEY")
+	c.QiniuAccessKey = c.accessKey.value
+	c.QiniuSecretKey = c.secretKey.value
+
+	if c.accessKey.err != nil {
+		return c.accessKey.err
+	}
+	return c.secretKey.err
+}
+
+// CredentialSources 返回 Access Key 和 Secret Key 的来源
+func (c *Config) CredentialSources() (accessKey, secretKey SecretInfo) {
+	return c.accessKey.info(), c.secretKey.info()
+}
+
+// resolveSecret 解析单个密钥，envName 为可直接覆盖该配置的环境变量
+func resolveSecret(ref, envName string) secretState {
+	state := secretState{ref: ref}
+	if v := os.Getenv(envName); v != "" && v == ref {
+		state.env = envName
+	}
+	state.value, state.err = Secrets().Resolve(ref)
+	return state
+}
+
+// info 来源描述
+func (s secretState) info() SecretInfo {
+	if s.env != "" {
+		return SecretInfo{Source: "环境变量 " + s.env}
+	}
+	return SecretInfo{Source: secret.Describe(s.ref), Err: s.err}
+}
+
+// raw 返回写入配置文件的值：未修改时保留原始引用
+func (s secretState) raw(current string) string {
+	if s.ref != "" && current == s.value {
+		return s.ref
+	}
+	return current
+}
+
+// envSecret 被环境变量覆盖的密钥，file 为配置文件中的原值
+type envSecret struct {
+	env  string
+	file string
+}
+
+// loadEnvSecrets 记录被环境变量覆盖的密钥，file 为读取的配置文件
+func loadEnvSecrets(file string) map[string]envSecret {
+	secrets := map[string]envSecret{}
+	var fileConfig *viper.Viper
+	for _, b := range envBindings {
+		if f, ok := LookupField(b.key); !ok || !f.Secret || os.Getenv(b.env) == "" {
+			continue
+		}
+		// 全局 viper 已绑定环境变量，单独读取配置文件获取原值
+		if fileConfig == nil && file != "" {
+			fileConfig = viper.New()
+			fileConfig.SetConfigFile(file)
+			if err := fileConfig.ReadInConfig(); err != nil {
+				fileConfig = nil
+			}
+		}
+		s := envSecret{env: b.env}
+		if fileConfig != nil {
+			s.file = fileConfig.GetString(b.key)
+		}
+		secrets[b.key] = s
+	}
+	return secrets
+}
+
+// persistedSecret 返回写入配置文件的顶层密钥，未修改的环境变量值替换为配置文件中的原值
+func (c *Config) persistedSecret(key, value string) string {
+	if s, ok := c.envSecrets[key]; ok && value == os.Getenv(s.env) {
+		return s.file
+	}
+	return value
+}
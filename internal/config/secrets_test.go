@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecretsKeepsReference(t *testing.T) {
+	t.Setenv("QU_TEST_SK", "resolved-sk")
+
+	cfg := &Config{QiniuAccessKey: "plain-ak", QiniuSecretKey: "env:QU_TEST_SK"}
+	cfg.base = cfg.account()
+	if err := cfg.ResolveSecrets(); err != nil {
+		t.Fatalf("ResolveSecrets() error: %v", err)
+	}
+
+	if cfg.QiniuSecretKey != "resolved-sk" {
+		t.Errorf("QiniuSecretKey = %q, expected resolved value", cfg.QiniuSecretKey)
+	}
+	if got := cfg.account().QiniuSecretKey; got != "env:QU_TEST_SK" {
+		t.Errorf("account().QiniuSecretKey = %q, expected original reference", got)
+	}
+
+	ak, sk := cfg.CredentialSources()
+	if ak.Source != "配置文件（明文）" || sk.Source != "环境变量 QU_TEST_SK" {
+		t.Errorf("CredentialSources() = %+v, %+v", ak, sk)
+	}
+
+	// 修改后的值按新值保存
+	cfg.QiniuSecretKey = "new-sk"
+	if got := cfg.account().QiniuSecretKey; got != "new-sk" {
+		t.Errorf("account().QiniuSecretKey after change = %q", got)
+	}
+}
+
+func TestResolveSecretsError(t *testing.T) {
+	cfg := &Config{QiniuSecretKey: "env:QU_TEST_SK_MISSING"}
+	if err := cfg.ResolveSecrets(); err == nil {
+		t.Fatal("expected error for missing environment variable")
+	}
+	if cfg.QiniuSecretKey != "" {
+		t.Errorf("QiniuSecretKey = %q, expected empty on error", cfg.QiniuSecretKey)
+	}
+	if _, sk := cfg.CredentialSources(); sk.Err == nil {
+		t.Error("expected CredentialSources to report the error")
+	}
+}
+
+func TestLoadKeepsSecretReferences(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("QINIU_UPLOADER_CONFIG_DIR", dir)
+	t.Setenv("QU_TEST_LOAD_SK", "resolved-sk")
+
+	path := filepath.Join(dir, "config.yaml")
+	content := "qiniu_access_key: plain-ak\nqiniu_secret_key: env:QU_TEST_LOAD_SK\nqiniu_bucket: assets\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	SetFile(path)
+	t.Cleanup(func() { SetFile("") })
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	// 加载和切换profile都不解析密钥，避免无关命令读取密钥环或执行 cmd: 引用
+	if cfg.QiniuSecretKey != "env:QU_TEST_LOAD_SK" {
+		t.Errorf("QiniuSecretKey after Load = %q, expected unresolved reference", cfg.QiniuSecretKey)
+	}
+	if err := cfg.UseProfile(DefaultProfile); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.QiniuSecretKey != "env:QU_TEST_LOAD_SK" {
+		t.Errorf("QiniuSecretKey after UseProfile = %q, expected unresolved reference", cfg.QiniuSecretKey)
+	}
+
+	if err := cfg.ResolveSecrets(); err != nil {
+		t.Fatalf("ResolveSecrets() error: %v", err)
+	}
+	if cfg.QiniuSecretKey != "resolved-sk" {
+		t.Errorf("QiniuSecretKey = %q, expected resolved value", cfg.QiniuSecretKey)
+	}
+}
+
+func TestSaveKeepsEnvSecretsOutOfFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("QINIU_UPLOADER_CONFIG_DIR", dir)
+	t.Setenv("QINIU_SECRET_KEY", "env-sk")
+
+	path := filepath.Join(dir, "config.yaml")
+	content := "qiniu_access_key: plain-ak\nqiniu_secret_key: keyring:default/secret_key\nqiniu_bucket: assets\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	SetFile(path)
+	t.Cleanup(func() {
+		SetFile("")
+	})
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.QiniuSecretKey != "env-sk" {
+		t.Fatalf("QiniuSecretKey = %q, expected environment value", cfg.QiniuSecretKey)
+	}
+	if err := cfg.Set("qiniu_bucket", "photos"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "env-sk") || !strings.Contains(string(data), "keyring:default/secret_key") {
+		t.Errorf("saved config should keep the file value instead of the environment value:\n%s", data)
+	}
+	if cfg.QiniuSecretKey != "env-sk" {
+		t.Errorf("QiniuSecretKey after Save = %q, expected environment value", cfg.QiniuSecretKey)
+	}
+}
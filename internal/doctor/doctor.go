@@ -0,0 +1,343 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"qiniu-uploader/internal/clipboard"
+	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/utils"
+	"qiniu-uploader/pkg/qiniu"
+)
+
+// Status 检查结果状态
+type Status int
+
+const (
+	StatusPass Status = iota
+	StatusWarn
+	StatusFail
+	StatusSkip
+)
+
+// 时钟偏差阈值：超过 warnSkew 提示，超过 failSkew 时上传凭证可能提前失效
+const (
+	warnSkew = time.Minute
+	failSkew = 15 * time.Minute
+)
+
+// probePrefix 写入检查使用的临时文件key前缀，检查结束后删除
+const probePrefix = ".qu-doctor/"
+
+// Result 单项检查结果
+type Result struct {
+	Name    string
+	Status  Status
+	Message string
+	// Fix 修复建议
+	Fix string
+}
+
+// Report 检查报告
+type Report struct {
+	Results []Result
+}
+
+// Remote 检查使用的七牛云接口，由 *qiniu.Client 实现
+type Remote interface {
+	CheckBucket() (*qiniu.BucketStatus, error)
+	BucketDomains() ([]string, error)
+	PutBytes(key string, data []byte) error
+	Delete(key string) error
+	FileURL(key string) string
+}
+
+// Doctor 检查配置、密钥、存储空间和本机环境
+type Doctor struct {
+	Config *config.Config
+	// Remote 为nil时跳过需要访问七牛云的检查
+	Remote Remote
+
+	// 以下字段便于测试时替换
+	Fetch      func(url string) (int, []byte, error)
+	ServerTime func() (time.Time, error)
+	Now        func() time.Time
+}
+
+// New 创建检查器，client 为nil时跳过需要访问七牛云的检查
+func New(cfg *config.Config, client *qiniu.Client) *Doctor {
+	d := &Doctor{
+		Config:     cfg,
+		Fetch:      fetch,
+		ServerTime: serverTime,
+		Now:        time.Now,
+	}
+	if client != nil {
+		d.Remote = client
+	}
+	return d
+}
+
+// Run 执行全部检查
+func (d *Doctor) Run() *Report {
+	r := &Report{}
+	if d.checkConfig(r) && d.Remote != nil {
+		d.checkRemote(r)
+	}
+	d.checkClock(r)
+	d.checkClipboard(r)
+	return r
+}
+
+// checkConfig 检查必填配置和密钥解析，返回是否可以继续远程检查
+func (d *Doctor) checkConfig(r *Report) bool {
+	cfg := d.Config
+	if cfg == nil {
+		r.add("配置", StatusFail, "配置未加载", "运行 'qu config init' 初始化配置，或运行 'qu config validate' 检查配置文件")
+		return false
+	}
+
+	ok := true
+	accessKey, secretKey := cfg.CredentialSources()
+	for _, item := range []struct {
+		name  string
+		key   string
+		value string
+		info  config.SecretInfo
+	}{
+		{"Access Key", "qiniu_access_key", cfg.QiniuAccessKey, accessKey},
+		{"Secret Key", "qiniu_secret_key", cfg.QiniuSecretKey, secretKey},
+	} {
+		switch {
+		case item.info.Err != nil:
+			r.add(item.name, StatusFail, fmt.Sprintf("读取失败 (%s): %v", item.info.Source, item.info.Err),
+				"检查密钥引用是否正确，或运行 'qu config set "+item.key+" <值>' 重新设置")
+			ok = false
+		case item.value == "":
+			r.add(item.name, StatusFail, "未设置", "运行 'qu config set "+item.key+" <值>' 或 'qu config init'")
+			ok = false
+		default:
+			r.add(item.name, StatusPass, "已设置 (来源: "+item.info.Source+")", "")
+		}
+	}
+	if cfg.QiniuBucket == "" {
+		r.add("存储空间", StatusFail, "未设置", "运行 'qu config set qiniu_bucket <名称>'")
+		ok = false
+	}
+	return ok
+}
+
+// checkRemote 检查密钥、存储空间、写入权限和域名
+func (d *Doctor) checkRemote(r *Report) {
+	cfg := d.Config
+
+	bucket, err := d.Remote.CheckBucket()
+	switch {
+	case errors.Is(err, qiniu.ErrUnauthorized):
+		r.add("密钥验证", StatusFail, err.Error(), "在七牛云控制台「密钥管理」中确认 AccessKey/SecretKey，然后运行 'qu config init' 重新设置")
+		return
+	case errors.Is(err, qiniu.ErrBucketNotFound):
+		r.add("密钥验证", StatusPass, "密钥有效", "")
+		r.add("存储空间", StatusFail, fmt.Sprintf("%s: %v", cfg.QiniuBucket, err), "检查名称拼写，或运行 'qu config set qiniu_bucket <名称>'")
+		return
+	case err != nil:
+		r.add("密钥验证", StatusFail, err.Error(), "检查网络连接后重试")
+		return
+	}
+	r.add("密钥验证", StatusPass, "密钥有效", "")
+	access := "公开空间"
+	if bucket.Private {
+		access = "私有空间"
+	}
+	r.add("存储空间", StatusPass, fmt.Sprintf("%s 存在（%s）", cfg.QiniuBucket, access), "")
+	r.add("区域", StatusPass, qiniu.RegionName(bucket.Region), "")
+
+	// 上传一个临时文件检查写入权限，检查结束后删除
+	key := fmt.Sprintf("%s%d.txt", probePrefix, d.Now().UnixNano())
+	content := []byte("qu doctor " + key)
+	if err := d.Remote.PutBytes(key, content); err != nil {
+		r.add("写入权限", StatusFail, err.Error(), "确认密钥对该存储空间有上传权限（子账号需授予上传权限）")
+		d.checkDomain(r, bucket, "", nil)
+		return
+	}
+	r.add("写入权限", StatusPass, "可以上传文件", "")
+	d.checkDomain(r, bucket, key, content)
+
+	if err := d.Remote.Delete(key); err != nil {
+		r.add("清理", StatusWarn, fmt.Sprintf("删除临时文件 %s 失败: %v", key, err), "在七牛云控制台手动删除该文件")
+	}
+}
+
+// checkDomain 检查域名是否绑定到存储空间，并通过临时文件检查能否访问
+func (d *Doctor) checkDomain(r *Report, bucket *qiniu.BucketStatus, key string, content []byte) {
+	domain := d.Config.QiniuDomain
+	if domain == "" {
+		r.add("域名", StatusWarn, "未设置，生成的链接无法访问", "在七牛云控制台为存储空间绑定域名，然后运行 'qu config set qiniu_domain <域名>'")
+		return
+	}
+
+	domains, err := d.Remote.BucketDomains()
+	switch {
+	case err != nil:
+		r.add("域名绑定", StatusWarn, err.Error(), "")
+	case !containsDomain(domains, domain):
+		fix := "在七牛云控制台为存储空间绑定该域名"
+		if len(domains) > 0 {
+			fix += "，或改用已绑定的域名: " + strings.Join(domains, ", ")
+		}
+		r.add("域名绑定", StatusFail, domain+" 未绑定到存储空间 "+d.Config.QiniuBucket, fix)
+		return
+	default:
+		r.add("域名绑定", StatusPass, domain+" 已绑定", "")
+	}
+
+	if key == "" {
+		r.add("域名访问", StatusSkip, "写入检查未通过，跳过", "")
+		return
+	}
+	url := d.Remote.FileURL(key)
+	status, body, err := d.Fetch(url)
+	switch {
+	case err != nil:
+		r.add("域名访问", StatusFail, fmt.Sprintf("访问 %s 失败: %v", url, err), "检查域名的 CNAME 解析和 HTTPS 证书是否已配置")
+	case bucket.Private && (status == http.StatusUnauthorized || status == http.StatusForbidden):
+		r.add("域名访问", StatusWarn, "私有空间的文件需要签名才能访问，生成的链接无法直接打开", "如需公开链接，请在七牛云控制台将存储空间设置为公开")
+	case status != http.StatusOK:
+		r.add("域名访问", StatusFail, fmt.Sprintf("访问 %s 返回 HTTP %d", url, status), "检查域名的 CNAME 解析和 HTTPS 证书是否已配置")
+	case !bytes.Equal(body, content):
+		r.add("域名访问", StatusWarn, "域名返回的内容与上传的文件不一致", "检查域名是否指向该存储空间，或CDN是否配置了回源改写")
+	default:
+		r.add("域名访问", StatusPass, "可以通过 https://"+domain+" 访问上传的文件", "")
+	}
+}
+
+// checkClock 检查本机时钟与七牛云服务器的偏差
+func (d *Doctor) checkClock(r *Report) {
+	server, err := d.ServerTime()
+	if err != nil {
+		r.add("时钟", StatusSkip, "无法获取服务器时间: "+err.Error(), "")
+		return
+	}
+
+	skew := d.Now().Sub(server).Round(time.Second)
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+	fix := "同步系统时间，如 Linux 运行 'sudo timedatectl set-ntp true'，WSL 运行 'sudo hwclock -s'"
+	switch {
+	case abs >= failSkew:
+		r.add("时钟", StatusFail, fmt.Sprintf("本机时间与服务器相差 %v，上传凭证可能失效", skew), fix)
+	case abs >= warnSkew:
+		r.add("时钟", StatusWarn, fmt.Sprintf("本机时间与服务器相差 %v", skew), fix)
+	default:
+		r.add("时钟", StatusPass, "本机时间准确", "")
+	}
+}
+
+// checkClipboard 检查运行环境和剪贴板工具
+func (d *Doctor) checkClipboard(r *Report) {
+	env := runtime.GOOS
+	if utils.IsWSLEnvironment() {
+		env += " (WSL，通过 Windows 剪贴板复制和读取图片)"
+	}
+	r.add("运行环境", StatusPass, env, "")
+
+	var copyCommand, pasteCommand string
+	if d.Config != nil {
+		copyCommand, pasteCommand = d.Config.ClipboardCommand, d.Config.ClipboardPasteCommand
+	}
+
+	if tool, err := clipboard.New(copyCommand); err != nil {
+		r.add("剪贴板", StatusWarn, err.Error(), "安装提示中的工具，或运行 'qu config set clipboard_command <命令>'")
+	} else {
+		r.add("剪贴板", StatusPass, "使用 "+tool.Name+" 复制链接", "")
+	}
+
+	if tool, err := clipboard.NewImagePaste(pasteCommand); err != nil {
+		r.add("剪贴板图片", StatusWarn, err.Error()+"，无法使用 'qu paste'", "安装提示中的工具，或运行 'qu config set clipboard_paste_command <命令>'")
+	} else {
+		r.add("剪贴板图片", StatusPass, "使用 "+tool.Name+" 读取图片", "")
+	}
+}
+
+// add 添加检查结果
+func (r *Report) add(name string, status Status, message, fix string) {
+	r.Results = append(r.Results, Result{Name: name, Status: status, Message: message, Fix: fix})
+}
+
+// Failed 是否存在未通过的检查
+func (r *Report) Failed() bool {
+	for _, result := range r.Results {
+		if result.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Print 输出检查报告
+func (r *Report) Print(w io.Writer) {
+	counts := map[Status]int{}
+	for _, result := range r.Results {
+		counts[result.Status]++
+		fmt.Fprintf(w, "%s %s: %s\n", result.Status.icon(), result.Name, result.Message)
+		if result.Fix != "" && (result.Status == StatusFail || result.Status == StatusWarn) {
+			fmt.Fprintf(w, "   👉 %s\n", result.Fix)
+		}
+	}
+	fmt.Fprintf(w, "\n结果: %d 项通过，%d 项警告，%d 项失败\n", counts[StatusPass], counts[StatusWarn], counts[StatusFail])
+}
+
+// icon 状态图标
+func (s Status) icon() string {
+	switch s {
+	case StatusPass:
+		return "✅"
+	case StatusWarn:
+		return "⚠️ "
+	case StatusFail:
+		return "❌"
+	}
+	return "⏭️ "
+}
+
+// containsDomain 判断域名列表中是否包含指定域名
+func containsDomain(domains []string, domain string) bool {
+	for _, d := range domains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch 下载URL的内容
+func fetch(url string) (int, []byte, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
+
+// serverTime 获取七牛云服务器时间
+func serverTime() (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return qiniu.ServerTime(ctx)
+}
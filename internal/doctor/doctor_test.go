@@ -0,0 +1,154 @@
+package doctor
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"qiniu-uploader/internal/config"
+	"qiniu-uploader/pkg/qiniu"
+)
+
+// fakeRemote 模拟七牛云接口，记录上传的临时文件
+type fakeRemote struct {
+	bucket    *qiniu.BucketStatus
+	bucketErr error
+	domains   []string
+	putErr    error
+	files     map[string][]byte
+}
+
+func (f *fakeRemote) CheckBucket() (*qiniu.BucketStatus, error) { return f.bucket, f.bucketErr }
+func (f *fakeRemote) BucketDomains() ([]string, error)          { return f.domains, nil }
+func (f *fakeRemote) FileURL(key string) string                 { return "https://cdn.example.com/" + key }
+
+func (f *fakeRemote) PutBytes(key string, data []byte) error {
+	if f.putErr != nil {
+		return f.putErr
+	}
+	f.files[key] = data
+	return nil
+}
+
+func (f *fakeRemote) Delete(key string) error {
+	delete(f.files, key)
+	return nil
+}
+
+func newTestDoctor(remote *fakeRemote, skew time.Duration) *Doctor {
+	cfg := &config.Config{
+		QiniuAccessKey: "ak",
+		QiniuSecretKey: "sk",
+		QiniuBucket:    "assets",
+		QiniuDomain:    "cdn.example.com",
+	}
+	_ = cfg.ResolveSecrets()
+
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	return &Doctor{
+		Config: cfg,
+		Remote: remote,
+		Fetch: func(url string) (int, []byte, error) {
+			key := strings.TrimPrefix(url, "https://cdn.example.com/")
+			if data, ok := remote.files[key]; ok {
+				return http.StatusOK, data, nil
+			}
+			return http.StatusNotFound, nil, nil
+		},
+		ServerTime: func() (time.Time, error) { return now.Add(-skew), nil },
+		Now:        func() time.Time { return now },
+	}
+}
+
+// statusOf 返回指定检查项的状态
+func statusOf(t *testing.T, r *Report, name string) Status {
+	t.Helper()
+	for _, result := range r.Results {
+		if result.Name == name {
+			return result.Status
+		}
+	}
+	t.Fatalf("check %q not found in report", name)
+	return StatusSkip
+}
+
+func TestRunAllPass(t *testing.T) {
+	remote := &fakeRemote{
+		bucket:  &qiniu.BucketStatus{Region: "z0"},
+		domains: []string{"cdn.example.com"},
+		files:   map[string][]byte{},
+	}
+	r := newTestDoctor(remote, 2*time.Second).Run()
+
+	for _, name := range []string{"密钥验证", "存储空间", "区域", "写入权限", "域名绑定", "域名访问", "时钟"} {
+		if got := statusOf(t, r, name); got != StatusPass {
+			t.Errorf("%s status = %v, expected pass", name, got)
+		}
+	}
+	if len(remote.files) != 0 {
+		t.Errorf("probe files not cleaned up: %v", remote.files)
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		remote *fakeRemote
+		skew   time.Duration
+		check  string
+		status Status
+	}{
+		{"Bad credentials", &fakeRemote{bucketErr: qiniu.ErrUnauthorized}, 0, "密钥验证", StatusFail},
+		{"Missing bucket", &fakeRemote{bucketErr: qiniu.ErrBucketNotFound}, 0, "存储空间", StatusFail},
+		{"Read only", &fakeRemote{bucket: &qiniu.BucketStatus{}, domains: []string{"cdn.example.com"}, putErr: errors.New("403")}, 0, "写入权限", StatusFail},
+		{"Unbound domain", &fakeRemote{bucket: &qiniu.BucketStatus{}, domains: []string{"other.example.com"}}, 0, "域名绑定", StatusFail},
+		{"Clock warning", &fakeRemote{bucket: &qiniu.BucketStatus{}, domains: []string{"cdn.example.com"}}, -5 * time.Minute, "时钟", StatusWarn},
+		{"Clock failure", &fakeRemote{bucket: &qiniu.BucketStatus{}, domains: []string{"cdn.example.com"}}, time.Hour, "时钟", StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.remote.files = map[string][]byte{}
+			r := newTestDoctor(tt.remote, tt.skew).Run()
+			if got := statusOf(t, r, tt.check); got != tt.status {
+				t.Errorf("%s status = %v, expected %v", tt.check, got, tt.status)
+			}
+			if tt.status == StatusFail && !r.Failed() {
+				t.Error("Failed() = false, expected true")
+			}
+		})
+	}
+}
+
+func TestRunMissingConfig(t *testing.T) {
+	d := newTestDoctor(&fakeRemote{files: map[string][]byte{}}, 0)
+	d.Config.QiniuBucket = ""
+	r := d.Run()
+
+	if got := statusOf(t, r, "存储空间"); got != StatusFail {
+		t.Errorf("存储空间 status = %v, expected fail", got)
+	}
+	for _, result := range r.Results {
+		if result.Name == "密钥验证" {
+			t.Error("remote checks should be skipped when config is incomplete")
+		}
+	}
+}
+
+func TestReportPrint(t *testing.T) {
+	r := &Report{}
+	r.add("密钥验证", StatusPass, "密钥有效", "")
+	r.add("域名", StatusFail, "未绑定", "绑定域名")
+
+	var buf bytes.Buffer
+	r.Print(&buf)
+	out := buf.String()
+	for _, want := range []string{"✅ 密钥验证: 密钥有效", "❌ 域名: 未绑定", "👉 绑定域名", "1 项通过，0 项警告，1 项失败"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Print() output missing %q:\n%s", want, out)
+		}
+	}
+}
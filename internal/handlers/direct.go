@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/history"
+	"qiniu-uploader/internal/middleware"
+	"qiniu-uploader/internal/models"
+	"qiniu-uploader/internal/services"
+	"qiniu-uploader/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pendingGrace 直传凭证过期后保留待确认记录的时间，凭证过期前开始的上传仍可确认
+const pendingGrace = time.Hour
+
+// DirectUploadHandler 浏览器直传处理器，签发上传凭证并确认上传结果
+type DirectUploadHandler struct {
+	config       *config.Config
+	qiniuService *services.QiniuService
+	history      *history.Store
+	pending      *pendingUploads
+}
+
+// NewDirectUploadHandler 创建浏览器直传处理器，history 为nil时不记录上传历史
+func NewDirectUploadHandler(cfg *config.Config, qiniuService *services.QiniuService, hist *history.Store) *DirectUploadHandler {
+	return &DirectUploadHandler{
+		config:       cfg,
+		qiniuService: qiniuService,
+		history:      hist,
+		pending:      &pendingUploads{items: map[string]pendingUpload{}},
+	}
+}
+
+// CreateToken 签发浏览器直传凭证和存储key
+func (h *DirectUploadHandler) CreateToken(c *gin.Context) {
+	var req models.DirectUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.DirectUploadResponse{
+			Success: false,
+			Message: "请求格式错误，需要 JSON 字段 filename",
+		})
+		return
+	}
+
+	filename := filepath.Base(req.Filename)
+	if err := utils.ValidateFilename(filename); err != nil {
+		c.JSON(http.StatusBadRequest, models.DirectUploadResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// 浏览器声明的大小和类型只用于提前拒绝，实际限制由凭证中的策略保证
+	mimeType := req.MimeType
+	if mimeType == "" {
+		mimeType = utils.GetMimeTypeFromExtension(filename)
+	}
+	if err := utils.ValidateUpload(req.Size, mimeType, h.config.MaxFileSize, h.config.AllowedTypes); err != nil {
+		c.JSON(http.StatusBadRequest, models.DirectUploadResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	upload, err := h.qiniuService.NewDirectUpload(filename)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.DirectUploadResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	h.pending.add(upload.Key, pendingUpload{
+		filename: filename,
+		tokenID:  tokenID(c),
+		expires:  upload.ExpiresAt.Add(pendingGrace),
+	})
+
+	c.JSON(http.StatusOK, models.DirectUploadResponse{
+		Success: true,
+		Message: "凭证已签发",
+		Data:    upload,
+	})
+}
+
+// CompleteUpload 确认浏览器直传完成，检查文件并记录上传历史
+func (h *DirectUploadHandler) CompleteUpload(c *gin.Context) {
+	var req models.CompleteUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.UploadResponse{
+			Success: false,
+			Message: "请求格式错误，需要 JSON 字段 key",
+		})
+		return
+	}
+
+	// 只接受本服务签发、且由同一令牌申请的key
+	pending, ok := h.pending.take(req.Key, tokenID(c))
+	if !ok {
+		c.JSON(http.StatusNotFound, models.UploadResponse{
+			Success: false,
+			Message: "没有该key的待确认上传",
+		})
+		return
+	}
+
+	response, err := h.qiniuService.CompleteDirectUpload(req.Key)
+	if err != nil {
+		var validationErr *utils.ValidationError
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrNotUploaded):
+			status = http.StatusConflict
+		case errors.As(err, &validationErr):
+			status = http.StatusBadRequest
+		}
+		// 文件未上传或查询失败时可以重试确认
+		if status != http.StatusBadRequest {
+			h.pending.add(req.Key, pending)
+		}
+		c.JSON(status, models.UploadResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.recordHistory(pending.filename, response)
+	c.JSON(http.StatusOK, response)
+}
+
+// callbackBodyLimit 七牛云回调请求体的大小上限
+const callbackBodyLimit = 64 << 10
+
+// Callback 处理七牛云上传回调，验证签名后检查文件并记录上传历史，
+// 响应内容由七牛云原样返回给上传端
+func (h *DirectUploadHandler) Callback(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, callbackBodyLimit)
+
+	// 签名覆盖请求体，伪造或篡改的回调在解析前拒绝
+	if !h.qiniuService.VerifyCallback(c.Request) {
+		c.JSON(http.StatusUnauthorized, models.UploadResponse{
+			Success: false,
+			Message: "回调签名无效",
+		})
+		return
+	}
+
+	key := c.PostForm("key")
+	size, err := strconv.ParseInt(c.PostForm("fsize"), 10, 64)
+	if key == "" || err != nil {
+		c.JSON(http.StatusBadRequest, models.UploadResponse{
+			Success: false,
+			Message: "回调内容错误，需要字段 key 和 fsize",
+		})
+		return
+	}
+
+	// 回调已确认上传，不再需要浏览器调用确认接口
+	filename := c.PostForm("filename")
+	if pending, ok := h.pending.remove(key); ok {
+		filename = pending.filename
+	} else if filename != "" {
+		filename = filepath.Base(filename)
+	}
+
+	response, err := h.qiniuService.FinishUpload(key, c.PostForm("hash"), size, c.PostForm("mime_type"))
+	if err != nil {
+		var validationErr *utils.ValidationError
+		status := http.StatusInternalServerError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.UploadResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.recordHistory(filename, response)
+	c.JSON(http.StatusOK, response)
+}
+
+// recordHistory 记录直传完成的文件，history 为nil时跳过
+func (h *DirectUploadHandler) recordHistory(filename string, response *models.UploadResponse) {
+	if h.history == nil {
+		return
+	}
+	entry := history.Entry{
+		LocalPath: filename,
+		Key:       response.Data.Key,
+		URL:       response.Data.URL,
+		Hash:      response.Data.Hash,
+		Size:      response.Data.FileSize,
+		MimeType:  response.Data.MimeType,
+		Bucket:    h.config.QiniuBucket,
+	}
+	if err := h.history.Add(entry); err != nil {
+		log.Printf("记录上传历史失败: %v", err)
+	}
+}
+
+// tokenID 返回当前请求的API令牌ID
+func tokenID(c *gin.Context) string {
+	if t := middleware.CurrentToken(c); t != nil {
+		return t.ID
+	}
+	return ""
+}
+
+// pendingUpload 已签发凭证、等待确认的直传
+type pendingUpload struct {
+	filename string
+	tokenID  string
+	expires  time.Time
+}
+
+// pendingUploads 待确认的直传，按存储key索引
+type pendingUploads struct {
+	mu    sync.Mutex
+	items map[string]pendingUpload
+}
+
+// add 记录待确认的直传，并清理已过期的记录
+func (p *pendingUploads) add(key string, upload pendingUpload) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for k, item := range p.items {
+		if now.After(item.expires) {
+			delete(p.items, k)
+		}
+	}
+	p.items[key] = upload
+}
+
+// take 取出由指定令牌申请且未过期的记录
+func (p *pendingUploads) take(key, tokenID string) (pendingUpload, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	upload, ok := p.items[key]
+	if !ok || upload.tokenID != tokenID || time.Now().After(upload.expires) {
+		return pendingUpload{}, false
+	}
+	delete(p.items, key)
+	return upload, true
+}
+
+// remove 取出任意令牌申请的记录，用于七牛云回调
+func (p *pendingUploads) remove(key string) (pendingUpload, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	upload, ok := p.items[key]
+	if ok {
+		delete(p.items, key)
+	}
+	return upload, ok
+}
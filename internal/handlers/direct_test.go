@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/qiniu/go-sdk/v7/auth/qbox"
+)
+
+func TestCallbackRejects(t *testing.T) {
+	cfg := config.Default()
+	cfg.QiniuAccessKey = "ak"
+	cfg.QiniuSecretKey = "sk"
+	cfg.QiniuBucket = "assets"
+	h := NewDirectUploadHandler(cfg, services.NewQiniuService(cfg), nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/callback/qiniu", h.Callback)
+
+	tests := []struct {
+		name     string
+		body     string
+		secret   string
+		expected int
+	}{
+		{"Unsigned", "key=a.png&fsize=10", "", http.StatusUnauthorized},
+		{"Forged", "key=a.png&fsize=10", "forged", http.StatusUnauthorized},
+		{"Missing key", "fsize=10", "sk", http.StatusBadRequest},
+		{"Invalid size", "key=a.png&fsize=big", "sk", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/callback/qiniu", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.secret != "" {
+				sign, err := qbox.NewMac("ak", tt.secret).SignRequest(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Authorization", "QBox "+sign)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expected {
+				t.Errorf("status = %d, expected %d: %s", w.Code, tt.expected, w.Body.String())
+			}
+		})
+	}
+}
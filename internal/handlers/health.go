@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"qiniu-uploader/internal/models"
+	"qiniu-uploader/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// readyTimeout 就绪检查访问七牛云的超时时间
+	readyTimeout = 3 * time.Second
+	// readyCacheTTL 就绪检查结果的缓存时间，避免探针频繁请求七牛云
+	readyCacheTTL = 5 * time.Second
+)
+
+// HealthHandler 存活和就绪检查处理器
+type HealthHandler struct {
+	qiniuService *services.QiniuService
+
+	mu      sync.Mutex
+	checked time.Time
+	lastErr error
+}
+
+// NewHealthHandler 创建健康检查处理器
+func NewHealthHandler(qiniuService *services.QiniuService) *HealthHandler {
+	return &HealthHandler{qiniuService: qiniuService}
+}
+
+// Liveness 存活检查，进程能处理请求即返回200
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, models.HealthResponse{Status: "ok"})
+}
+
+// Readiness 就绪检查，七牛云不可访问或密钥无效时返回503
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	if err := h.check(c.Request.Context()); err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.HealthResponse{
+			Status:  "unavailable",
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.HealthResponse{Status: "ok"})
+}
+
+// check 返回缓存的检查结果，过期后重新检查；同时到达的探针只检查一次
+func (h *HealthHandler) check(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if time.Since(h.checked) < readyCacheTTL {
+		return h.lastErr
+	}
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+	h.lastErr = h.qiniuService.CheckReady(ctx)
+	h.checked = time.Now()
+	return h.lastErr
+}
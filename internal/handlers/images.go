@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"qiniu-uploader/internal/middleware"
+	"qiniu-uploader/internal/models"
+	"qiniu-uploader/internal/services"
+	"qiniu-uploader/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetImage 获取单个文件详情
+func (h *UploadHandler) GetImage(c *gin.Context) {
+	key, ok := imageKey(c)
+	if !ok {
+		return
+	}
+
+	image, err := h.qiniuService.GetImage(key)
+	if err != nil {
+		c.JSON(imageErrorStatus(err), models.ImageResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ImageResponse{
+		Success: true,
+		Message: "获取成功",
+		Data:    image,
+	})
+}
+
+// DeleteImage 删除单个文件
+func (h *UploadHandler) DeleteImage(c *gin.Context) {
+	key, ok := imageKey(c)
+	if !ok {
+		return
+	}
+
+	if err := h.qiniuService.DeleteImage(key); err != nil {
+		c.JSON(imageErrorStatus(err), models.ImageResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ImageResponse{
+		Success: true,
+		Message: "删除成功",
+	})
+}
+
+// UpdateImage 重命名文件，修改MIME类型或自定义元数据
+func (h *UploadHandler) UpdateImage(c *gin.Context) {
+	key, ok := imageKey(c)
+	if !ok {
+		return
+	}
+
+	var req models.ImageUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ImageResponse{
+			Success: false,
+			Message: "请求格式错误",
+		})
+		return
+	}
+	if err := validateImageUpdate(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ImageResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// 重命名会移走原文件，与删除一样需要 delete 权限
+	if req.Key != "" && req.Key != key {
+		if t := middleware.CurrentToken(c); t == nil || !t.HasScope(token.ScopeDelete) {
+			c.JSON(http.StatusForbidden, models.ImageResponse{
+				Success: false,
+				Message: "重命名文件需要API令牌的 " + token.ScopeDelete + " 权限",
+			})
+			return
+		}
+	}
+
+	image, err := h.qiniuService.UpdateImage(key, req)
+	if err != nil {
+		response := models.ImageResponse{
+			Success: false,
+			Message: err.Error(),
+		}
+		// 重命名已生效时返回新文件的详情，客户端据此更新引用
+		var partial *services.PartialUpdateError
+		if errors.As(err, &partial) {
+			response.Data, _ = h.qiniuService.GetImage(partial.Key)
+		}
+		c.JSON(imageErrorStatus(err), response)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ImageResponse{
+		Success: true,
+		Message: "修改成功",
+		Data:    image,
+	})
+}
+
+// BatchDeleteImages 批量删除文件，部分失败时返回207
+func (h *UploadHandler) BatchDeleteImages(c *gin.Context) {
+	var req models.BatchDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Keys) == 0 {
+		c.JSON(http.StatusBadRequest, models.BatchDeleteResponse{
+			Success: false,
+			Message: "请求格式错误，需要 JSON 字段 keys",
+		})
+		return
+	}
+	if len(req.Keys) > services.MaxBatchDelete {
+		c.JSON(http.StatusBadRequest, models.BatchDeleteResponse{
+			Success: false,
+			Message: fmt.Sprintf("单次最多删除 %d 个文件", services.MaxBatchDelete),
+		})
+		return
+	}
+
+	items, err := h.qiniuService.BatchDelete(req.Keys)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.BatchDeleteResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.BatchDeleteResponse{
+		Data:  items,
+		Total: len(items),
+	}
+	for _, item := range items {
+		if !item.Success {
+			response.Failed++
+		}
+	}
+
+	status := http.StatusOK
+	switch {
+	case response.Failed == 0:
+		response.Success = true
+		response.Message = "删除成功"
+	case response.Failed < response.Total:
+		status = http.StatusMultiStatus
+		response.Message = fmt.Sprintf("%d 个文件删除失败", response.Failed)
+	default:
+		status = batchDeleteStatus(items)
+		response.Message = "全部文件删除失败"
+	}
+	c.JSON(status, response)
+}
+
+// batchDeleteStatus 全部文件删除失败时的状态码：都不存在时为404，
+// 存在七牛云服务端错误时为502，其他为500
+func batchDeleteStatus(items []models.BatchDeleteItem) int {
+	status := http.StatusNotFound
+	for _, item := range items {
+		switch item.Status {
+		case http.StatusNotFound:
+		case http.StatusBadGateway:
+			return http.StatusBadGateway
+		default:
+			status = http.StatusInternalServerError
+		}
+	}
+	return status
+}
+
+// imageKey 返回路径中的存储key（可包含 /），为空时返回400
+func imageKey(c *gin.Context) (string, bool) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, models.ImageResponse{
+			Success: false,
+			Message: "请指定存储key",
+		})
+		return "", false
+	}
+	return key, true
+}
+
+// validateImageUpdate 校验修改请求，至少需要修改一项
+func validateImageUpdate(req *models.ImageUpdateRequest) error {
+	req.Key = strings.TrimSpace(req.Key)
+	req.MimeType = strings.TrimSpace(req.MimeType)
+	if req.Key == "" && req.MimeType == "" && len(req.Metadata) == 0 {
+		return errors.New("请至少指定 key、mime_type 或 metadata 中的一项")
+	}
+	if strings.HasPrefix(req.Key, "/") {
+		return errors.New("存储key不能以 / 开头")
+	}
+	if req.MimeType != "" && !strings.Contains(req.MimeType, "/") {
+		return fmt.Errorf("无效的MIME类型: %s", req.MimeType)
+	}
+	for name, value := range req.Metadata {
+		if name == "" || value == "" {
+			return errors.New("元数据的名称和值不能为空")
+		}
+	}
+	return nil
+}
+
+// imageErrorStatus 文件操作错误对应的状态码
+func imageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrKeyExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/middleware"
+	"qiniu-uploader/internal/models"
+	"qiniu-uploader/internal/services"
+	"qiniu-uploader/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestImageRequestValidation(t *testing.T) {
+	cfg := config.Default()
+	cfg.QiniuBucket = "assets"
+	h := NewUploadHandler(cfg, services.NewQiniuService(cfg))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/images", h.GetImages)
+	router.GET("/api/images/*key", h.GetImage)
+	router.PATCH("/api/images/*key", h.UpdateImage)
+	router.DELETE("/api/images/*key", h.DeleteImage)
+	router.POST("/api/images/batch-delete", h.BatchDeleteImages)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"Delete without key", http.MethodDelete, "/api/images/", ""},
+		{"Empty update", http.MethodPatch, "/api/images/a/b.png", `{}`},
+		{"Absolute rename", http.MethodPatch, "/api/images/a/b.png", `{"key":"/c.png"}`},
+		{"Invalid mime", http.MethodPatch, "/api/images/a/b.png", `{"mime_type":"png"}`},
+		{"Batch without keys", http.MethodPost, "/api/images/batch-delete", `{"keys":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, expected 400: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestValidateImageUpdate(t *testing.T) {
+	req := models.ImageUpdateRequest{Key: "  new/key.png ", Metadata: map[string]string{"author": "qu"}}
+	if err := validateImageUpdate(&req); err != nil {
+		t.Fatalf("validateImageUpdate() error: %v", err)
+	}
+	if req.Key != "new/key.png" {
+		t.Errorf("Key = %q, expected trimmed key", req.Key)
+	}
+}
+
+func TestUpdateImageRenameScope(t *testing.T) {
+	cfg := config.Default()
+	cfg.QiniuBucket = "assets"
+	h := NewUploadHandler(cfg, services.NewQiniuService(cfg))
+
+	store := token.Open(t.TempDir())
+	raw, _, err := store.Create(token.CreateOptions{Scopes: []string{token.ScopeUpload}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api", middleware.Auth(store, 1024))
+	api.PATCH("/images/*key", middleware.RequireScope(token.ScopeUpload), h.UpdateImage)
+
+	// 只有 upload 权限的令牌不能重命名文件
+	req := httptest.NewRequest(http.MethodPatch, "/api/images/a/b.png", bytes.NewBufferString(`{"key":"a/c.png"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+raw)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, expected 403: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestBatchDeleteStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		want     int
+	}{
+		{"All not found", []int{http.StatusNotFound, http.StatusNotFound}, http.StatusNotFound},
+		{"Upstream error", []int{http.StatusNotFound, http.StatusBadGateway}, http.StatusBadGateway},
+		{"Other error", []int{http.StatusNotFound, http.StatusInternalServerError}, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := make([]models.BatchDeleteItem, len(tt.statuses))
+			for i, status := range tt.statuses {
+				items[i].Status = status
+			}
+			if got := batchDeleteStatus(items); got != tt.want {
+				t.Errorf("batchDeleteStatus() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageErrorStatusPartialUpdate(t *testing.T) {
+	err := &services.PartialUpdateError{Key: "c.png", Err: errors.New("change meta failed")}
+	if got := imageErrorStatus(err); got != http.StatusInternalServerError {
+		t.Errorf("imageErrorStatus() = %d, want %d", got, http.StatusInternalServerError)
+	}
+}
@@ -1,13 +1,22 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/history"
 	"qiniu-uploader/internal/models"
 	"qiniu-uploader/internal/services"
 	"qiniu-uploader/internal/utils"
+	"qiniu-uploader/pkg/imaging"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,53 +33,276 @@ func NewUploadHandler(cfg *config.Config, qiniuService *services.QiniuService) *
 	}
 }
 
-// UploadImage 处理图片上传
+// multipartOverhead 请求中除文件内容外的表单开销上限
+const multipartOverhead = 1 << 20
+
+// formFieldLimit 普通表单字段的大小上限
+const formFieldLimit = 4 << 10
+
+// maxBatchFiles 单个请求最多上传的文件数
+const maxBatchFiles = 20
+
+// uploadWorkers 同时上传的文件数，也是同时缓存在服务端的文件数
+const uploadWorkers = 4
+
+// MaxRequestSize 上传请求体的大小上限
+func MaxRequestSize(cfg *config.Config) int64 {
+	return cfg.MaxFileSize*maxBatchFiles + multipartOverhead
+}
+
+// UploadImage 处理图片上传，逐段读取 multipart 请求，不在内存中缓存整个请求。
+// 单个 file 字段返回单文件结果；files[]、files 或多个 file 字段时并发上传并返回每个文件的结果。
+// keep_metadata、convert 等表单字段对位于其后的文件生效，也可以通过查询参数传递
 func (h *UploadHandler) UploadImage(c *gin.Context) {
-	// 获取上传的文件
-	file, header, err := c.Request.FormFile("file")
+	// 请求体大小由 MaxBytesReader 限制，不信任 Content-Length
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxRequestSize(h.config))
+
+	reader, err := c.Request.MultipartReader()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.UploadResponse{
 			Success: false,
-			Message: "请选择要上传的文件",
+			Message: "请使用 multipart/form-data 上传文件",
 		})
 		return
 	}
-	defer file.Close()
 
-	// 验证文件类型
-	if err := utils.ValidateFilename(header.Filename); err != nil {
-		c.JSON(http.StatusBadRequest, models.UploadResponse{
+	var (
+		items   []*models.BatchUploadItem
+		batch   bool
+		readErr error
+		wg      sync.WaitGroup
+		slots   = make(chan struct{}, uploadWorkers)
+		fields  = map[string]string{}
+	)
+	for readErr == nil {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+
+		switch part.FormName() {
+		case "file":
+		case "files[]", "files":
+			batch = true
+		default:
+			if part.FileName() == "" {
+				value, _ := io.ReadAll(io.LimitReader(part, formFieldLimit))
+				fields[part.FormName()] = string(value)
+			}
+			continue
+		}
+		if len(items) == maxBatchFiles {
+			readErr = fmt.Errorf("单次最多上传 %d 个文件", maxBatchFiles)
+			break
+		}
+
+		item := &models.BatchUploadItem{Filename: part.FileName()}
+		items = append(items, item)
+		opts, err := h.validatePart(c, part, fields)
+		if err != nil {
+			failItem(item, http.StatusBadRequest, err.Error())
+			continue
+		}
+
+		// 等待空闲的上传槽位后再读取文件，限制同时缓存的文件数
+		slots <- struct{}{}
+		body := &limitedReader{r: part, remaining: h.config.MaxFileSize}
+		file, err := services.SpoolFile(body)
+		if err != nil {
+			<-slots
+			switch {
+			case body.exceeded:
+				failItem(item, http.StatusRequestEntityTooLarge, "文件大小超过限制")
+			case body.err != nil:
+				failItem(item, readErrorStatus(body.err), "读取上传内容失败")
+				readErr = body.err
+			default:
+				failItem(item, http.StatusInternalServerError, err.Error())
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			defer file.Close()
+
+			response, err := h.qiniuService.UploadSpooled(file, item.Filename, opts)
+			if err != nil {
+				failItem(item, http.StatusInternalServerError, err.Error())
+				return
+			}
+			item.Status = http.StatusOK
+			item.UploadResponse = *response
+		}()
+	}
+	wg.Wait()
+
+	if len(items) == 0 {
+		status, message := http.StatusBadRequest, "请选择要上传的文件"
+		if readErr != nil {
+			status, message = readErrorStatus(readErr), "读取上传内容失败: "+readErr.Error()
+		}
+		c.JSON(status, models.UploadResponse{
 			Success: false,
-			Message: err.Error(),
+			Message: message,
 		})
 		return
 	}
 
-	// 验证文件大小和类型
-	if err := utils.ValidateFile(c.Request, h.config.MaxFileSize, h.config.AllowedTypes); err != nil {
-		c.JSON(http.StatusBadRequest, models.UploadResponse{
-			Success: false,
-			Message: err.Error(),
-		})
+	if !batch && len(items) == 1 && readErr == nil {
+		c.JSON(items[0].Status, items[0].UploadResponse)
 		return
 	}
+	c.JSON(batchResponse(items, readErr))
+}
+
+// validatePart 验证文件名和MIME类型，返回该文件的预处理策略，文件大小在读取时检查
+func (h *UploadHandler) validatePart(c *gin.Context, part *multipart.Part, fields map[string]string) (imaging.Options, error) {
+	opts := h.config.ImageOptions()
+	if err := utils.ValidateFilename(part.FileName()); err != nil {
+		return opts, err
+	}
+	if err := utils.ValidateUpload(0, part.Header.Get("Content-Type"), h.config.MaxFileSize, h.config.AllowedTypes); err != nil {
+		return opts, err
+	}
+
+	// 预处理策略与命令行一致，keep_metadata=true 时保留元数据，convert 指定转换格式
+	if keep, _ := strconv.ParseBool(formValue(c, fields, "keep_metadata")); keep {
+		opts.StripMetadata = false
+	}
+	if convert := formValue(c, fields, "convert"); convert != "" {
+		format, err := imaging.ParseConvertFormat(convert)
+		if err != nil {
+			return opts, err
+		}
+		opts.Convert = format
+	}
+	return opts, nil
+}
+
+// failItem 记录单个文件的失败结果
+func failItem(item *models.BatchUploadItem, status int, message string) {
+	item.Status = status
+	item.UploadResponse = models.UploadResponse{
+		Success: false,
+		Message: message,
+	}
+}
+
+// batchResponse 汇总批量上传结果：全部成功为200，部分失败为207，全部失败时使用各文件相同的状态码，
+// 状态码不同时为400；请求体读取失败时使用读取错误的状态码
+func batchResponse(items []*models.BatchUploadItem, readErr error) (int, models.BatchUploadResponse) {
+	response := models.BatchUploadResponse{
+		Data:  make([]models.BatchUploadItem, len(items)),
+		Total: len(items),
+	}
+	status := 0
+	for i, item := range items {
+		response.Data[i] = *item
+		if item.Success {
+			continue
+		}
+		response.Failed++
+		if status == 0 {
+			status = item.Status
+		} else if status != item.Status {
+			status = http.StatusBadRequest
+		}
+	}
+
+	response.Success = response.Failed == 0 && readErr == nil
+	switch {
+	case readErr != nil:
+		response.Message = "读取上传内容失败: " + readErr.Error()
+		return readErrorStatus(readErr), response
+	case response.Failed == 0:
+		response.Message = "上传成功"
+		return http.StatusOK, response
+	case response.Failed < response.Total:
+		response.Message = fmt.Sprintf("%d 个文件上传失败", response.Failed)
+		return http.StatusMultiStatus, response
+	default:
+		response.Message = "全部文件上传失败"
+		return status, response
+	}
+}
+
+// formValue 返回表单字段，未提供时使用查询参数
+func formValue(c *gin.Context, fields map[string]string, name string) string {
+	if value, ok := fields[name]; ok {
+		return value
+	}
+	return c.Query(name)
+}
 
-	// 读取文件内容
-	fileData, err := io.ReadAll(file)
+// readErrorStatus 读取请求体失败时的状态码，超过 MaxBytesReader 限制时为413
+func readErrorStatus(err error) int {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// limitedReader 限制读取的字节数，超出时返回错误并记录，同时记录读取请求体的错误
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+	err       error
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		l.exceeded = true
+		return 0, errors.New("文件大小超过限制")
+	}
+	// 多读一个字节以判断是否超出限制
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		l.exceeded = true
+		return 0, errors.New("文件大小超过限制")
+	}
+	if err != nil && err != io.EOF {
+		l.err = err
+	}
+	return n, err
+}
+
+// 图片列表每页数量
+const (
+	defaultListLimit = 50
+	maxListLimit     = 1000
+)
+
+// GetImages 分页获取图片列表，支持 limit、marker、prefix、delimiter、mime、since、until 参数
+func (h *UploadHandler) GetImages(c *gin.Context) {
+	query, err := parseListQuery(c, time.Now())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.UploadResponse{
+		c.JSON(http.StatusBadRequest, models.ImageListResponse{
 			Success: false,
-			Message: "读取文件失败",
+			Message: err.Error(),
+			Data:    []models.ImageInfo{},
 		})
 		return
 	}
 
-	// 上传到七牛云
-	response, err := h.qiniuService.UploadFile(fileData, header.Filename)
+	response, err := h.qiniuService.ListImages(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.UploadResponse{
+		c.JSON(http.StatusInternalServerError, models.ImageListResponse{
 			Success: false,
 			Message: err.Error(),
+			Data:    []models.ImageInfo{},
 		})
 		return
 	}
@@ -78,24 +310,56 @@ func (h *UploadHandler) UploadImage(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// GetImages 获取图片列表
-func (h *UploadHandler) GetImages(c *gin.Context) {
-	prefix := c.Query("prefix")
-	limit := 50 // 默认限制50张图片
+// parseListQuery 解析列表查询参数
+func parseListQuery(c *gin.Context, now time.Time) (models.ImageListQuery, error) {
+	query := models.ImageListQuery{
+		Limit:     defaultListLimit,
+		Marker:    c.Query("marker"),
+		Prefix:    c.Query("prefix"),
+		Delimiter: c.Query("delimiter"),
+	}
 
-	images, err := h.qiniuService.GetFileList(prefix, limit)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ImageListResponse{
-			Success: false,
-			Data:    []models.ImageInfo{},
-			Total:   0,
-		})
-		return
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return query, fmt.Errorf("limit 需要 1-%d 之间的整数", maxListLimit)
+		}
+		query.Limit = limit
+	}
+
+	for _, value := range strings.Split(c.Query("mime"), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			query.MimeTypes = append(query.MimeTypes, value)
+		}
 	}
 
-	c.JSON(http.StatusOK, models.ImageListResponse{
-		Success: true,
-		Data:    images,
-		Total:   len(images),
-	})
-}
\ No newline at end of file
+	var err error
+	if query.Since, err = parseTimeParam(c.Query("since"), now); err != nil {
+		return query, fmt.Errorf("无效的 since: %v", err)
+	}
+	if query.Until, err = parseTimeParam(c.Query("until"), now); err != nil {
+		return query, fmt.Errorf("无效的 until: %v", err)
+	}
+	if !query.Since.IsZero() && !query.Until.IsZero() && query.Since.After(query.Until) {
+		return query, fmt.Errorf("since 不能晚于 until")
+	}
+	return query, nil
+}
+
+// parseTimeParam 解析时间参数，支持 RFC3339、2006-01-02 和相对时间（如 7d 表示7天前），为空时返回零值
+func parseTimeParam(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	d, err := history.ParseSince(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("支持 RFC3339、2006-01-02 或 7d 等相对时间: %s", value)
+	}
+	return now.Add(-d), nil
+}
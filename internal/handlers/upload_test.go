@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/models"
+	"qiniu-uploader/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newMultipartBody 创建包含单个文件字段的请求体
+func newMultipartBody(t *testing.T, filename, contentType string, data []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := w.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	w.Close()
+	return &buf, w.FormDataContentType()
+}
+
+func TestUploadImageRejects(t *testing.T) {
+	cfg := config.Default()
+	cfg.QiniuBucket = "assets"
+	cfg.MaxFileSize = 64
+	h := NewUploadHandler(cfg, services.NewQiniuService(cfg))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/upload", h.UploadImage)
+
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		size        int
+		status      int
+	}{
+		{"Too large", "a.png", "image/png", 128, http.StatusRequestEntityTooLarge},
+		{"Request over limit", "a.png", "image/png", 2 << 20, http.StatusRequestEntityTooLarge},
+		{"Wrong type", "a.png", "text/plain", 10, http.StatusBadRequest},
+		{"Wrong extension", "a.txt", "image/png", 10, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := newMultipartBody(t, tt.filename, tt.contentType, bytes.Repeat([]byte("x"), tt.size))
+			req := httptest.NewRequest(http.MethodPost, "/api/upload", body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, expected %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUploadImageBatchValidation(t *testing.T) {
+	cfg := config.Default()
+	cfg.QiniuBucket = "assets"
+	h := NewUploadHandler(cfg, services.NewQiniuService(cfg))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/upload", h.UploadImage)
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, name := range []string{"a.txt", "b.exe"} {
+		part, err := w.CreateFormFile("files[]", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte("data"))
+	}
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/upload", &buf)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, expected 400", rec.Code)
+	}
+	var response models.BatchUploadResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Total != 2 || response.Failed != 2 || response.Data[1].Filename != "b.exe" {
+		t.Errorf("unexpected batch response: %+v", response)
+	}
+}
+
+func TestBatchResponseStatus(t *testing.T) {
+	ok := func() *models.BatchUploadItem {
+		return &models.BatchUploadItem{Status: http.StatusOK, UploadResponse: models.UploadResponse{Success: true}}
+	}
+	failed := func(status int) *models.BatchUploadItem {
+		item := &models.BatchUploadItem{}
+		failItem(item, status, "failed")
+		return item
+	}
+
+	tests := []struct {
+		name    string
+		items   []*models.BatchUploadItem
+		readErr error
+		status  int
+	}{
+		{"All succeeded", []*models.BatchUploadItem{ok(), ok()}, nil, http.StatusOK},
+		{"Partial failure", []*models.BatchUploadItem{ok(), failed(http.StatusBadRequest)}, nil, http.StatusMultiStatus},
+		{"All too large", []*models.BatchUploadItem{failed(413), failed(413)}, nil, http.StatusRequestEntityTooLarge},
+		{"Mixed failures", []*models.BatchUploadItem{failed(413), failed(500)}, nil, http.StatusBadRequest},
+		{"Read error", []*models.BatchUploadItem{ok()}, &http.MaxBytesError{Limit: 1}, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := batchResponse(tt.items, tt.readErr)
+			if status != tt.status {
+				t.Errorf("batchResponse() status = %d, expected %d", status, tt.status)
+			}
+			if response.Success != (tt.status == http.StatusOK) {
+				t.Errorf("batchResponse() success = %v", response.Success)
+			}
+		})
+	}
+}
+
+func TestParseListQuery(t *testing.T) {
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		query   string
+		check   func(q models.ImageListQuery) bool
+		wantErr bool
+	}{
+		{"Defaults", "", func(q models.ImageListQuery) bool { return q.Limit == defaultListLimit && q.MimeTypes == nil }, false},
+		{"Cursor", "limit=10&marker=abc&prefix=a/&delimiter=/", func(q models.ImageListQuery) bool {
+			return q.Limit == 10 && q.Marker == "abc" && q.Prefix == "a/" && q.Delimiter == "/"
+		}, false},
+		{"Mime list", "mime=image/png,+image/webp", func(q models.ImageListQuery) bool {
+			return len(q.MimeTypes) == 2 && q.MimeTypes[1] == "image/webp"
+		}, false},
+		{"Relative since", "since=7d&until=2024-03-09T00:00:00Z", func(q models.ImageListQuery) bool {
+			return q.Since.Equal(now.AddDate(0, 0, -7)) && q.Until.Equal(now.Add(-12*time.Hour))
+		}, false},
+		{"Limit too large", "limit=5000", nil, true},
+		{"Invalid limit", "limit=abc", nil, true},
+		{"Invalid since", "since=yesterday", nil, true},
+		{"Reversed range", "since=2024-03-09&until=2024-03-01", nil, true},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/images?"+tt.query, nil)
+			q, err := parseListQuery(c, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseListQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil && !tt.check(q) {
+				t.Errorf("parseListQuery() = %+v", q)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName 历史记录文件名，位于配置目录下
+const FileName = "history.jsonl"
+
+// ServerFileName HTTP服务记录浏览器直传的文件名，与命令行的历史分开，
+// 避免 qu history/undo 看到并删除其他客户端上传的文件
+const ServerFileName = "server-history.jsonl"
+
+const (
+	// lockTimeout 等待其他进程释放历史记录写锁的最长时间
+	lockTimeout = 5 * time.Second
+	// staleLockAge 锁文件超过该时间视为进程异常退出遗留，直接清除
+	staleLockAge = 30 * time.Second
+)
+
+// Entry 一条上传记录
+type Entry struct {
+	LocalPath  string    `json:"local_path"`
+	Key        string    `json:"key"`
+	URL        string    `json:"url"`
+	Hash       string    `json:"hash"`
+	Size       int64     `json:"size"`
+	MimeType   string    `json:"mime_type,omitempty"`
+	Width      int       `json:"width,omitempty"`
+	Height     int       `json:"height,omitempty"`
+	Bucket     string    `json:"bucket,omitempty"`
+	UploadedAt time.Time `json:"uploaded_at"`
+	// Overwritten 为true表示上传时覆盖了云端已存在的同名文件，撤销会删除原有文件
+	Overwritten bool `json:"overwritten,omitempty"`
+}
+
+// Filter 查询条件
+type Filter struct {
+	// Search 匹配本地路径、存储key或URL（不区分大小写）
+	Search string
+	// Since 只返回该时间之后的记录，零值表示不限制
+	Since time.Time
+}
+
+// Item 查询结果，Index 为记录在全部历史中的序号（1 表示最近一次上传）
+type Item struct {
+	Index int
+	Entry
+}
+
+// Store 本地上传历史，以JSON Lines格式追加写入
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore 创建历史记录存储
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Open 打开配置目录下的历史记录
+func Open(dir string) *Store {
+	return NewStore(filepath.Join(dir, FileName))
+}
+
+// OpenServer 打开配置目录下HTTP服务的上传记录
+func OpenServer(dir string) *Store {
+	return NewStore(filepath.Join(dir, ServerFileName))
+}
+
+// Path 历史记录文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Add 追加一条上传记录
+func (s *Store) Add(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.UploadedAt.IsZero() {
+		entry.UploadedAt = time.Now()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("打开历史记录失败: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入历史记录失败: %v", err)
+	}
+	return nil
+}
+
+// All 返回全部记录，最近的在前
+func (s *Store) All() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+// Find 按条件查询记录，最近的在前
+func (s *Store) Find(filter Filter) ([]Item, error) {
+	entries, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+
+	search := strings.ToLower(filter.Search)
+	var items []Item
+	for i, e := range entries {
+		if !filter.Since.IsZero() && e.UploadedAt.Before(filter.Since) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(e.LocalPath+"\n"+e.Key+"\n"+e.URL), search) {
+			continue
+		}
+		items = append(items, Item{Index: i + 1, Entry: e})
+	}
+	return items, nil
+}
+
+// Get 返回第 n 条记录（1 表示最近一次上传）
+func (s *Store) Get(n int) (*Entry, error) {
+	entries, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(entries) {
+		return nil, fmt.Errorf("历史记录 #%d 不存在（共 %d 条）", n, len(entries))
+	}
+	return &entries[n-1], nil
+}
+
+// Remove 删除指定的记录（按存储key和上传时间匹配）
+func (s *Store) Remove(targets ...Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	var kept []Entry
+	for _, e := range entries {
+		if !containsEntry(targets, e) {
+			kept = append(kept, e)
+		}
+	}
+	return s.write(kept)
+}
+
+// lock 获取跨进程的写锁。多个 qu 进程同时上传和撤销时，
+// 避免 Remove 重写文件覆盖其他进程刚追加的记录；同一进程内由 mu 串行
+func (s *Store) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return nil, fmt.Errorf("创建历史记录目录失败: %v", err)
+	}
+
+	path := s.path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("锁定历史记录失败: %v", err)
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("历史记录正被其他进程占用，如确认没有其他 qu 进程，请删除 %s", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// containsEntry 判断记录是否在列表中
+func containsEntry(list []Entry, e Entry) bool {
+	for _, t := range list {
+		if t.Key == e.Key && t.UploadedAt.Equal(e.UploadedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// write 以临时文件替换的方式重写全部记录，entries 为最近的在前
+func (s *Store) write(entries []Entry) error {
+	var buf bytes.Buffer
+	for i := len(entries) - 1; i >= 0; i-- {
+		line, err := json.Marshal(entries[i])
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("写入历史记录失败: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入历史记录失败: %v", err)
+	}
+	return nil
+}
+
+// read 读取全部记录并倒序，损坏的行会被跳过
+func (s *Store) read() ([]Entry, error) {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取历史记录失败: %v", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取历史记录失败: %v", err)
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// ParseSince 解析时间范围，支持 30m、12h、7d、2w 等格式
+func ParseSince(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	unit := value[len(value)-1]
+	if unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("无效的时间范围: %s（示例: 7d、12h、2w）", value)
+		}
+		days := n
+		if unit == 'w' {
+			days = n * 7
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("无效的时间范围: %s（示例: 7d、12h、2w）", value)
+	}
+	return d, nil
+}
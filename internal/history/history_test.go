@@ -0,0 +1,228 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStoreAddAndFind(t *testing.T) {
+	store := Open(t.TempDir())
+
+	now := time.Now()
+	entries := []Entry{
+		{LocalPath: "/home/me/diagram.png", Key: "images/1.png", URL: "https://cdn.example.com/images/1.png", UploadedAt: now.Add(-10 * 24 * time.Hour)},
+		{LocalPath: "/home/me/photo.jpg", Key: "images/2.jpg", URL: "https://cdn.example.com/images/2.jpg", UploadedAt: now.Add(-2 * time.Hour)},
+		{LocalPath: "/home/me/Diagram-v2.png", Key: "images/3.png", URL: "https://cdn.example.com/images/3.png", UploadedAt: now},
+	}
+	for _, e := range entries {
+		if err := store.Add(e); err != nil {
+			t.Fatalf("Add() error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []int
+	}{
+		{"All", Filter{}, []int{1, 2, 3}},
+		{"Search is case-insensitive", Filter{Search: "diagram"}, []int{1, 3}},
+		{"Search by key", Filter{Search: "images/2"}, []int{2}},
+		{"Since", Filter{Since: now.Add(-7 * 24 * time.Hour)}, []int{1, 2}},
+		{"Search and since", Filter{Search: "diagram", Since: now.Add(-7 * 24 * time.Hour)}, []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := store.Find(tt.filter)
+			if err != nil {
+				t.Fatalf("Find() error: %v", err)
+			}
+			if len(items) != len(tt.expected) {
+				t.Fatalf("Find() returned %d items, expected %d", len(items), len(tt.expected))
+			}
+			for i, item := range items {
+				if item.Index != tt.expected[i] {
+					t.Errorf("item %d index = %d, expected %d", i, item.Index, tt.expected[i])
+				}
+			}
+		})
+	}
+
+	latest, err := store.Get(1)
+	if err != nil {
+		t.Fatalf("Get(1) error: %v", err)
+	}
+	if latest.Key != "images/3.png" {
+		t.Errorf("Get(1).Key = %s, expected images/3.png", latest.Key)
+	}
+	if _, err := store.Get(4); err == nil {
+		t.Error("expected error for out-of-range index")
+	}
+}
+
+func TestStoreRemove(t *testing.T) {
+	store := Open(t.TempDir())
+
+	now := time.Now().Truncate(time.Second)
+	for i, key := range []string{"images/1.png", "images/2.png", "images/1.png"} {
+		if err := store.Add(Entry{Key: key, UploadedAt: now.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	latest, err := store.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Remove(*latest); err != nil {
+		t.Fatalf("Remove() error: %v", err)
+	}
+
+	entries, err := store.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同名key的旧记录不应被删除
+	if len(entries) != 2 || entries[0].Key != "images/2.png" || entries[1].Key != "images/1.png" {
+		t.Errorf("All() after Remove = %+v", entries)
+	}
+}
+
+func TestStoreConcurrentWriters(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "qu")
+	// 两个 Store 不共享互斥锁，模拟两个 qu 进程
+	adder, remover := Open(dir), Open(dir)
+
+	now := time.Now().Truncate(time.Second)
+	old := Entry{Key: "old.png", UploadedAt: now}
+	if err := adder.Add(old); err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 50
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			if err := adder.Add(Entry{Key: fmt.Sprintf("%d.png", i), UploadedAt: now.Add(time.Duration(i+1) * time.Second)}); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			if err := remover.Remove(old); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	entries, err := adder.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != n {
+		t.Errorf("got %d entries, want %d", len(entries), n)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0700 {
+		t.Errorf("history dir mode = %v, want 0700", info.Mode().Perm())
+	}
+	if _, err := os.Stat(adder.Path() + ".lock"); !os.IsNotExist(err) {
+		t.Error("lock file should be removed after writing")
+	}
+}
+
+func TestStoreStaleLock(t *testing.T) {
+	store := Open(t.TempDir())
+	lock := store.Path() + ".lock"
+	if err := os.WriteFile(lock, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	stale := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(lock, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Add(Entry{Key: "a.png"}); err != nil {
+		t.Errorf("Add() with stale lock error: %v", err)
+	}
+}
+
+func TestStoreMissingAndCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	store := Open(dir)
+
+	entries, err := store.All()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("All() on missing file = %v, %v", entries, err)
+	}
+
+	content := "not json\n{\"key\":\"images/1.png\"}\n"
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = store.All()
+	if err != nil {
+		t.Fatalf("All() error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Key != "images/1.png" {
+		t.Errorf("All() = %+v, expected the single valid entry", entries)
+	}
+}
+
+func TestOpenServerSeparateFromCLI(t *testing.T) {
+	dir := t.TempDir()
+	if err := OpenServer(dir).Add(Entry{Key: "browser.png"}); err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+
+	entries, err := Open(dir).All()
+	if err != nil {
+		t.Fatalf("All() error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("CLI history = %+v, expected server uploads to be kept separately", entries)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"", 0, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"30m", 30 * time.Minute, false},
+		{"d", 0, true},
+		{"-1d", 0, true},
+		{"yesterday", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSince(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSince(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseSince(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
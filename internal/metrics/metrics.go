@@ -0,0 +1,76 @@
+// Package metrics 以 Prometheus 文本格式提供服务端指标
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+// Default 服务端使用的指标集合，由 /metrics 输出
+var Default = NewRegistry()
+
+// 请求耗时和上传耗时的桶上界（秒）
+var (
+	requestBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+	uploadBuckets  = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+)
+
+var (
+	// HTTPRequests HTTP请求数，route 为路由模板
+	HTTPRequests = Default.NewCounter("qu_http_requests_total", "HTTP请求数", "method", "route", "status")
+	// HTTPRequestDuration HTTP请求耗时
+	HTTPRequestDuration = Default.NewHistogram("qu_http_request_duration_seconds", "HTTP请求耗时（秒）", requestBuckets, "method", "route", "status")
+	// UploadBytes 上传到七牛云的字节数，method 为 form、resumable 或 direct（浏览器直传）
+	UploadBytes = Default.NewCounter("qu_upload_bytes_total", "上传到七牛云的字节数", "method")
+	// UploadDuration 服务端上传到七牛云的耗时
+	UploadDuration = Default.NewHistogram("qu_upload_duration_seconds", "服务端上传到七牛云的耗时（秒）", uploadBuckets, "method", "result")
+	// UploadsInFlight 正在上传到七牛云的文件数
+	UploadsInFlight = Default.NewGauge("qu_uploads_in_flight", "正在上传到七牛云的文件数")
+	// QiniuErrors 七牛云接口错误数，class 见 ErrorClass
+	QiniuErrors = Default.NewCounter("qu_qiniu_errors_total", "七牛云接口错误数", "operation", "class")
+)
+
+// ObserveQiniuError 按错误类别记录七牛云接口错误，err 为nil时不记录
+func ObserveQiniuError(operation string, err error) {
+	if err != nil {
+		QiniuErrors.Inc(operation, ErrorClass(err))
+	}
+}
+
+// ErrorClass 返回七牛云接口错误的类别：
+// timeout 超时，network 网络错误，auth 认证失败，not_found 文件或空间不存在，
+// conflict 文件已存在，client 其他请求错误，server 七牛云服务端错误，other 其他错误
+func ErrorClass(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	}
+
+	var errInfo *storage.ErrorInfo
+	if !errors.As(err, &errInfo) {
+		return "other"
+	}
+	switch code := errInfo.Code; {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return "auth"
+	case code == 612 || code == 631:
+		return "not_found"
+	case code == 614:
+		return "conflict"
+	case code >= 500 && code < 600:
+		return "server"
+	case code >= 400:
+		return "client"
+	}
+	return "other"
+}
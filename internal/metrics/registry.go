@@ -0,0 +1,246 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType Prometheus 文本格式的 Content-Type
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Registry 指标集合，按注册顺序以 Prometheus 文本格式输出
+type Registry struct {
+	mu      sync.Mutex
+	metrics []*metric
+}
+
+// NewRegistry 创建空的指标集合
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// metric 一个指标及其按标签值区分的序列
+type metric struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series 一组标签值对应的数据，直方图的 counts 为落在各个桶内的次数（未累计）
+type series struct {
+	values []string
+	value  float64
+	counts []uint64
+	count  uint64
+}
+
+// Counter 只增不减的计数器
+type Counter struct{ m *metric }
+
+// Gauge 可增可减的数值
+type Gauge struct{ m *metric }
+
+// Histogram 按上界分桶统计的直方图
+type Histogram struct{ m *metric }
+
+// NewCounter 注册计数器，labels 为标签名
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(name, help, "counter", labels, nil)}
+}
+
+// NewGauge 注册数值指标，labels 为标签名
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(name, help, "gauge", labels, nil)}
+}
+
+// NewHistogram 注册直方图，buckets 为递增的桶上界，+Inf 桶自动添加
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: %s 的桶上界必须递增", name))
+	}
+	return &Histogram{r.register(name, help, "histogram", labels, buckets)}
+}
+
+func (r *Registry) register(name, help, kind string, labels []string, buckets []float64) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range r.metrics {
+		if m.name == name {
+			panic(fmt.Sprintf("metrics: 重复注册指标 %s", name))
+		}
+	}
+	m := &metric{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  map[string]*series{},
+	}
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// Inc 计数加1，values 为各标签的值
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add 计数增加 v，v 不能为负数
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: 计数器 %s 不能减少", c.m.name))
+	}
+	c.m.update(values, func(s *series) { s.value += v })
+}
+
+// Inc 数值加1
+func (g *Gauge) Inc(values ...string) {
+	g.Add(1, values...)
+}
+
+// Dec 数值减1
+func (g *Gauge) Dec(values ...string) {
+	g.Add(-1, values...)
+}
+
+// Add 数值增加 v
+func (g *Gauge) Add(v float64, values ...string) {
+	g.m.update(values, func(s *series) { s.value += v })
+}
+
+// Set 设置数值
+func (g *Gauge) Set(v float64, values ...string) {
+	g.m.update(values, func(s *series) { s.value = v })
+}
+
+// Observe 记录一次观测值
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.m.update(values, func(s *series) {
+		s.value += v
+		s.count++
+		if i := sort.SearchFloat64s(h.m.buckets, v); i < len(s.counts) {
+			s.counts[i]++
+		}
+	})
+}
+
+// update 在锁内修改标签值对应的序列，序列不存在时创建
+func (m *metric) update(values []string, fn func(*series)) {
+	if len(values) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s 需要 %d 个标签值，实际为 %d 个", m.name, len(m.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		if m.kind == "histogram" {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+	fn(s)
+}
+
+// Write 以 Prometheus 文本格式输出全部指标
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]*metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler 返回输出全部指标的HTTP处理器
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_ = r.Write(w)
+	})
+}
+
+func (m *metric) write(w *bufio.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", m.name, escape(m.help, false))
+	fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.kind)
+
+	// 没有标签的计数器和数值未更新时也输出0
+	if len(m.series) == 0 && len(m.labels) == 0 && m.kind != "histogram" {
+		fmt.Fprintf(w, "%s 0\n", m.name)
+		return
+	}
+
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := m.series[key]
+		if m.kind != "histogram" {
+			fmt.Fprintf(w, "%s%s %s\n", m.name, m.labelString(s.values, ""), formatFloat(s.value))
+			continue
+		}
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", m.name, m.labelString(s.values, formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", m.name, m.labelString(s.values, "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", m.name, m.labelString(s.values, ""), formatFloat(s.value))
+		fmt.Fprintf(w, "%s_count%s %d\n", m.name, m.labelString(s.values, ""), s.count)
+	}
+}
+
+// labelString 生成 {name="value",...}，le 不为空时追加直方图的桶标签
+func (m *metric) labelString(values []string, le string) string {
+	pairs := make([]string, 0, len(values)+1)
+	for i, value := range values {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, m.labels[i], escape(value, true)))
+	}
+	if le != "" {
+		pairs = append(pairs, fmt.Sprintf(`le="%s"`, le))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escape 转义说明和标签值中的特殊字符
+func escape(s string, quote bool) string {
+	replacements := []string{`\`, `\\`, "\n", `\n`}
+	if quote {
+		replacements = append(replacements, `"`, `\"`)
+	}
+	return strings.NewReplacer(replacements...).Replace(s)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
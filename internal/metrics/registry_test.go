@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("test_requests_total", "请求数", "route", "status")
+	inFlight := r.NewGauge("test_in_flight", "进行中")
+	duration := r.NewHistogram("test_duration_seconds", "耗时", []float64{0.1, 1}, "route")
+
+	requests.Inc("/api/images", "200")
+	requests.Add(2, "/api/images", "200")
+	requests.Inc(`/a"b`, "500")
+	inFlight.Inc()
+	inFlight.Inc()
+	inFlight.Dec()
+	duration.Observe(0.05, "/api")
+	duration.Observe(0.1, "/api")
+	duration.Observe(3, "/api")
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP test_requests_total 请求数
+# TYPE test_requests_total counter
+test_requests_total{route="/a\"b",status="500"} 1
+test_requests_total{route="/api/images",status="200"} 3
+# HELP test_in_flight 进行中
+# TYPE test_in_flight gauge
+test_in_flight 1
+# HELP test_duration_seconds 耗时
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/api",le="0.1"} 2
+test_duration_seconds_bucket{route="/api",le="1"} 2
+test_duration_seconds_bucket{route="/api",le="+Inf"} 3
+test_duration_seconds_sum{route="/api"} 3.15
+test_duration_seconds_count{route="/api"} 3
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestRegistryPanics(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounter("test_total", "计数", "route")
+
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"Duplicate name", func() { r.NewGauge("test_total", "重复") }},
+		{"Wrong label count", func() { counter.Inc() }},
+		{"Negative counter", func() { counter.Add(-1, "/") }},
+		{"Unsorted buckets", func() { r.NewHistogram("test_seconds", "耗时", []float64{1, 0.1}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{context.DeadlineExceeded, "timeout"},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, "network"},
+		{&storage.ErrorInfo{Code: 401}, "auth"},
+		{&storage.ErrorInfo{Code: 612}, "not_found"},
+		{&storage.ErrorInfo{Code: 631}, "not_found"},
+		{&storage.ErrorInfo{Code: 614}, "conflict"},
+		{&storage.ErrorInfo{Code: 400}, "client"},
+		{&storage.ErrorInfo{Code: 599}, "server"},
+		{fmt.Errorf("上传失败: %w", &storage.ErrorInfo{Code: 502}), "server"},
+		{errors.New("unknown"), "other"},
+	}
+	for _, tt := range tests {
+		if got := ErrorClass(tt.err); got != tt.expected {
+			t.Errorf("ErrorClass(%v) = %q, expected %q", tt.err, got, tt.expected)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "计数")
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Header().Get("Content-Type") != contentType || !strings.Contains(w.Body.String(), "test_total 0\n") {
+		t.Errorf("unexpected response %v %q", w.Header(), w.Body.String())
+	}
+}
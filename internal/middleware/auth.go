@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"qiniu-uploader/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenContextKey 认证通过的令牌在 gin.Context 中的键
+const tokenContextKey = "qu.token"
+
+// Auth API令牌认证中间件，支持 Authorization: Bearer 令牌和HMAC签名请求，
+// maxBody 限制签名请求的请求体大小（签名请求需要先计算请求体摘要）
+func Auth(store *token.Store, maxBody int64) gin.HandlerFunc {
+	replay := newReplayCache()
+
+	return func(c *gin.Context) {
+		var (
+			t   *token.Token
+			err error
+		)
+		if token.IsSigned(c.Request) {
+			var cleanup func()
+			t, cleanup, err = verifySigned(c, store, replay, maxBody)
+			defer cleanup()
+		} else {
+			t, err = verifyBearer(c.Request, store)
+		}
+		if err != nil {
+			status := http.StatusUnauthorized
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			c.Header("WWW-Authenticate", `Bearer realm="qu"`)
+			c.AbortWithStatusJSON(status, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.Set(tokenContextKey, t)
+		c.Next()
+	}
+}
+
+// RequireScope 要求令牌具有指定权限，需在 Auth 之后使用
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t := CurrentToken(c)
+		if t == nil || !t.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "API令牌没有 " + scope + " 权限",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentToken 返回当前请求认证通过的令牌，未认证时返回nil
+func CurrentToken(c *gin.Context) *token.Token {
+	if v, ok := c.Get(tokenContextKey); ok {
+		if t, ok := v.(*token.Token); ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// verifyBearer 验证 Authorization: Bearer 令牌
+func verifyBearer(req *http.Request, store *token.Store) (*token.Token, error) {
+	header := req.Header.Get("Authorization")
+	scheme, raw, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(raw) == "" {
+		return nil, errors.New("缺少API令牌，请使用 Authorization: Bearer <令牌>")
+	}
+	return store.Verify(strings.TrimSpace(raw))
+}
+
+// verifySigned 验证HMAC签名请求，请求体边读边计算摘要并暂存到临时文件，验证后交给后续处理器读取
+// 返回的 cleanup 用于在请求处理完成后删除临时文件
+func verifySigned(c *gin.Context, store *token.Store, replay *replayCache, maxBody int64) (*token.Token, func(), error) {
+	bodyHash, cleanup, err := spoolBody(c, maxBody)
+	if err != nil {
+		return nil, cleanup, err
+	}
+
+	now := time.Now()
+	t, err := store.VerifySignature(c.Request, bodyHash, now)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	// 签名在有效期内只能使用一次，防止重放
+	if !replay.add(c.Request.Header.Get(token.HeaderSignature), now) {
+		return nil, cleanup, errors.New("重复的签名请求")
+	}
+	return t, cleanup, nil
+}
+
+// spoolBody 将请求体写入临时文件并返回其SHA-256
+func spoolBody(c *gin.Context, maxBody int64) (string, func(), error) {
+	hasher := sha256.New()
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return hex.EncodeToString(hasher.Sum(nil)), func() {}, nil
+	}
+
+	tmp, err := os.CreateTemp("", "qu-body-*")
+	if err != nil {
+		return "", func() {}, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	body := c.Request.Body
+	if maxBody > 0 {
+		body = http.MaxBytesReader(c.Writer, body, maxBody)
+	}
+	if _, err := io.Copy(io.MultiWriter(tmp, hasher), body); err != nil {
+		return "", cleanup, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", cleanup, err
+	}
+	c.Request.Body = io.NopCloser(tmp)
+	return hex.EncodeToString(hasher.Sum(nil)), cleanup, nil
+}
+
+// replayCache 记录有效期内已使用的签名
+type replayCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newReplayCache() *replayCache {
+	return &replayCache{seen: map[string]time.Time{}}
+}
+
+// add 记录签名，已使用过时返回false；超过时钟偏差窗口的记录被清理
+func (r *replayCache) add(signature string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for sig, at := range r.seen {
+		if now.Sub(at) > 2*token.MaxClockSkew {
+			delete(r.seen, sig)
+		}
+	}
+	if _, ok := r.seen[signature]; ok {
+		return false
+	}
+	r.seen[signature] = now
+	return true
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"qiniu-uploader/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter 创建需要认证的测试路由，处理器返回读取到的请求体
+func newTestRouter(store *token.Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api", Auth(store, 1024))
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	}
+	api.POST("/upload", RequireScope(token.ScopeUpload), echo)
+	api.DELETE("/images", RequireScope(token.ScopeDelete), echo)
+	return router
+}
+
+func TestAuthBearer(t *testing.T) {
+	store := token.Open(t.TempDir())
+	raw, _, err := store.Create(token.CreateOptions{Scopes: []string{token.ScopeUpload}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newTestRouter(store)
+
+	tests := []struct {
+		name   string
+		method string
+		auth   string
+		status int
+	}{
+		{"Missing token", http.MethodPost, "", http.StatusUnauthorized},
+		{"Invalid token", http.MethodPost, "Bearer qu_000000000000_x", http.StatusUnauthorized},
+		{"Valid token", http.MethodPost, "Bearer " + raw, http.StatusOK},
+		{"Missing scope", http.MethodDelete, "Bearer " + raw, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/api/upload"
+			if tt.method == http.MethodDelete {
+				path = "/api/images"
+			}
+			req := httptest.NewRequest(tt.method, path, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, expected %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthSigned(t *testing.T) {
+	store := token.Open(t.TempDir())
+	raw, _, err := store.Create(token.CreateOptions{HMAC: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newTestRouter(store)
+
+	body := []byte("payload")
+	req := httptest.NewRequest(http.MethodPost, "/api/upload", bytes.NewReader(body))
+	if err := token.SignRequest(req, raw, body, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	header := req.Header.Clone()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "payload" {
+		t.Fatalf("signed request = %d %q, expected 200 with body", w.Code, w.Body.String())
+	}
+
+	// 相同签名重放被拒绝
+	replay := httptest.NewRequest(http.MethodPost, "/api/upload", bytes.NewReader(body))
+	replay.Header = header
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, replay)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("replayed request status = %d, expected 401", w.Code)
+	}
+
+	// HMAC令牌不能作为 Bearer 令牌使用
+	bearer := httptest.NewRequest(http.MethodPost, "/api/upload", nil)
+	bearer.Header.Set("Authorization", "Bearer "+raw)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, bearer)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("bearer with HMAC token status = %d, expected 401", w.Code)
+	}
+
+	// 超过大小限制的请求体
+	large := bytes.Repeat([]byte("x"), 2048)
+	req = httptest.NewRequest(http.MethodPost, "/api/upload", bytes.NewReader(large))
+	if err := token.SignRequest(req, raw, large, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body status = %d, expected 413", w.Code)
+	}
+}
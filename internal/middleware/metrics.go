@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"qiniu-uploader/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 按方法、路由模板和状态码记录请求数和耗时，未匹配的路由记为 unmatched，非标准方法记为 other
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		// 使用路由模板而不是请求路径，避免文件key等参数产生大量序列
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := metricsMethod(c.Request.Method)
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequests.Inc(method, route, status)
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), method, route, status)
+	}
+}
+
+// metricsMethod 标准方法原样返回，其他方法记为 other，避免任意方法名产生大量序列
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
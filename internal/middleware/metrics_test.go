@@ -0,0 +1,25 @@
+package middleware
+
+import "testing"
+
+func TestMetricsMethod(t *testing.T) {
+	tests := []struct {
+		method   string
+		expected string
+	}{
+		{"GET", "GET"},
+		{"PATCH", "PATCH"},
+		{"OPTIONS", "OPTIONS"},
+		{"get", "other"},
+		{"PROPFIND", "other"},
+		{"X-RANDOM-1234", "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			if got := metricsMethod(tt.method); got != tt.expected {
+				t.Errorf("metricsMethod(%q) = %q, expected %q", tt.method, got, tt.expected)
+			}
+		})
+	}
+}
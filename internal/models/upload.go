@@ -1,28 +1,147 @@
 package models
 
+import "time"
+
 type UploadResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
 	Data    struct {
-		Key      string `json:"key"`
-		Hash     string `json:"hash"`
-		URL      string `json:"url"`
-		FileSize int64  `json:"file_size"`
-		MimeType string `json:"mime_type"`
+		Key          string `json:"key"`
+		Hash         string `json:"hash"`
+		URL          string `json:"url"`
+		FileSize     int64  `json:"file_size"`
+		OriginalSize int64  `json:"original_size"`
+		MimeType     string `json:"mime_type"`
+
+		MetadataRemoved []string `json:"metadata_removed,omitempty"`
+		GPSRemoved      bool     `json:"gps_removed,omitempty"`
+		ConvertedFrom   string   `json:"converted_from,omitempty"`
+		// ConvertSkipped 请求了格式转换但保留了原格式时的原因
+		ConvertSkipped string `json:"convert_skipped,omitempty"`
 	} `json:"data,omitempty"`
 }
 
+// BatchUploadItem 批量上传中单个文件的结果，Status 为该文件单独上传时的HTTP状态码
+type BatchUploadItem struct {
+	Filename string `json:"filename"`
+	Status   int    `json:"status"`
+	UploadResponse
+}
+
+// BatchUploadResponse 批量上传结果，Data 与请求中的文件顺序一致
+type BatchUploadResponse struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    []BatchUploadItem `json:"data"`
+	Total   int               `json:"total"`
+	Failed  int               `json:"failed"`
+}
+
 type ImageInfo struct {
-	ID       string `json:"id"`
-	Key      string `json:"key"`
-	URL      string `json:"url"`
-	FileSize int64  `json:"file_size"`
-	MimeType string `json:"mime_type"`
-	Uploaded string `json:"uploaded"`
+	ID           string `json:"id"`
+	Key          string `json:"key"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	FileSize     int64  `json:"file_size"`
+	MimeType     string `json:"mime_type"`
+	Uploaded     string `json:"uploaded"`
+
+	// Metadata 自定义元数据，只在获取单个文件详情时返回
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ImageResponse 单个文件的操作结果，与 UploadResponse 使用相同的结构
+type ImageResponse struct {
+	Success bool       `json:"success"`
+	Message string     `json:"message"`
+	Data    *ImageInfo `json:"data,omitempty"`
+}
+
+// ImageUpdateRequest 修改文件的请求，未提供的字段保持不变
+type ImageUpdateRequest struct {
+	// Key 新的存储key，用于重命名
+	Key      string            `json:"key"`
+	MimeType string            `json:"mime_type"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// BatchDeleteRequest 批量删除请求
+type BatchDeleteRequest struct {
+	Keys []string `json:"keys" binding:"required"`
+}
+
+// BatchDeleteItem 批量删除中单个文件的结果，Status 为该文件单独删除时的HTTP状态码
+type BatchDeleteItem struct {
+	Key     string `json:"key"`
+	Status  int    `json:"status"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
 }
 
+type BatchDeleteResponse struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    []BatchDeleteItem `json:"data"`
+	Total   int               `json:"total"`
+	Failed  int               `json:"failed"`
+}
+
+// ImageListQuery 文件列表查询条件
+type ImageListQuery struct {
+	Limit     int
+	Marker    string
+	Prefix    string
+	Delimiter string
+	// MimeTypes 只返回匹配的MIME类型，支持 image/* 通配，为空时不限制
+	MimeTypes []string
+	// Since/Until 上传时间范围，零值表示不限制
+	Since time.Time
+	Until time.Time
+}
+
+// ImageListResponse 文件列表，NextMarker 为下一页的游标，Prefixes 为使用 delimiter 时的目录
 type ImageListResponse struct {
-	Success bool        `json:"success"`
-	Data    []ImageInfo `json:"data"`
-	Total   int         `json:"total"`
-}
\ No newline at end of file
+	Success    bool        `json:"success"`
+	Message    string      `json:"message,omitempty"`
+	Data       []ImageInfo `json:"data"`
+	Count      int         `json:"count"`
+	NextMarker string      `json:"next_marker"`
+	HasMore    bool        `json:"has_more"`
+	Prefixes   []string    `json:"prefixes"`
+}
+
+// DirectUploadRequest 申请浏览器直传凭证的请求
+type DirectUploadRequest struct {
+	Filename string `json:"filename" binding:"required"`
+	// Size 和 MimeType 为浏览器声明的文件信息，用于提前拒绝不符合限制的文件
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type"`
+}
+
+// DirectUpload 浏览器直传凭证，浏览器以 token 和 key 作为表单字段上传到 upload_url
+type DirectUpload struct {
+	Token        string    `json:"token"`
+	Key          string    `json:"key"`
+	UploadURL    string    `json:"upload_url"`
+	URL          string    `json:"url"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	MaxFileSize  int64     `json:"max_file_size"`
+	AllowedTypes []string  `json:"allowed_types"`
+}
+
+type DirectUploadResponse struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message"`
+	Data    *DirectUpload `json:"data,omitempty"`
+}
+
+// CompleteUploadRequest 浏览器直传完成后的确认请求
+type CompleteUploadRequest struct {
+	Key string `json:"key" binding:"required"`
+}
+
+// HealthResponse 健康检查结果，status 为 ok 或 unavailable
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
@@ -3,29 +3,42 @@ package routes
 import (
 	"qiniu-uploader/internal/config"
 	"qiniu-uploader/internal/handlers"
+	"qiniu-uploader/internal/history"
+	"qiniu-uploader/internal/metrics"
 	"qiniu-uploader/internal/middleware"
 	"qiniu-uploader/internal/services"
+	"qiniu-uploader/internal/token"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRoutes 设置路由
-func SetupRoutes(router *gin.Engine, cfg *config.Config) {
+// SetupRoutes 设置路由，API接口需要 tokens 中的令牌认证，浏览器直传完成后记录到 hist
+func SetupRoutes(router *gin.Engine, cfg *config.Config, tokens *token.Store, hist *history.Store) {
 	// 初始化服务
 	qiniuService := services.NewQiniuService(cfg)
 	uploadHandler := handlers.NewUploadHandler(cfg, qiniuService)
+	directHandler := handlers.NewDirectUploadHandler(cfg, qiniuService, hist)
+	healthHandler := handlers.NewHealthHandler(qiniuService)
 
 	// 全局中间件
+	router.Use(middleware.Metrics())
 	router.Use(middleware.CORS())
 
+	// 健康检查和监控指标，供 Kubernetes 探针和 Prometheus 访问，不需要API令牌
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
+	router.GET("/metrics", gin.WrapH(metrics.Default.Handler()))
+
 	// 静态文件服务
 	router.Static("/static", "./web/static")
 
 	// API路由组
 	api := router.Group("/api")
+	// 签名请求的请求体上限与上传接口相同
+	api.Use(middleware.Auth(tokens, handlers.MaxRequestSize(cfg)))
 	{
 		// 上传相关路由
-		upload := api.Group("/upload")
+		upload := api.Group("/upload", middleware.RequireScope(token.ScopeUpload))
 		{
 			upload.POST("", uploadHandler.UploadImage)
 		}
@@ -33,12 +46,27 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 		// 图片相关路由
 		images := api.Group("/images")
 		{
-			images.GET("", uploadHandler.GetImages)
+			images.GET("", middleware.RequireScope(token.ScopeList), uploadHandler.GetImages)
+			images.GET("/*key", middleware.RequireScope(token.ScopeList), uploadHandler.GetImage)
+			// 修改MIME类型和元数据需要 upload 权限，重命名（key）另外需要 delete 权限
+			images.PATCH("/*key", middleware.RequireScope(token.ScopeUpload), uploadHandler.UpdateImage)
+			images.DELETE("/*key", middleware.RequireScope(token.ScopeDelete), uploadHandler.DeleteImage)
+			images.POST("/batch-delete", middleware.RequireScope(token.ScopeDelete), uploadHandler.BatchDeleteImages)
+		}
+
+		// 浏览器直传路由
+		api.POST("/token", middleware.RequireScope(token.ScopeUpload), directHandler.CreateToken)
+		uploads := api.Group("/uploads", middleware.RequireScope(token.ScopeUpload))
+		{
+			uploads.POST("/complete", directHandler.CompleteUpload)
 		}
 	}
 
+	// 七牛云上传回调，使用七牛云密钥签名认证，不需要API令牌
+	router.POST("/api/callback/qiniu", directHandler.Callback)
+
 	// 默认路由
 	router.GET("/", func(c *gin.Context) {
 		c.File("./web/static/index.html")
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,221 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// PassphraseEnv 加密密钥文件口令的环境变量
+const PassphraseEnv = "QINIU_UPLOADER_PASSPHRASE"
+
+// scrypt 参数
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// EncryptedFile 以口令加密的密钥文件（scrypt + AES-256-GCM）
+type EncryptedFile struct {
+	path       string
+	passphrase func(confirm bool) ([]byte, error)
+
+	mu      sync.Mutex
+	secrets map[string]string
+	key     []byte
+	salt    []byte
+}
+
+// encryptedPayload 密钥文件的磁盘格式
+type encryptedPayload struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// NewEncryptedFile 创建加密密钥文件，passphrase 用于获取口令，confirm 为true表示新建文件需要确认口令
+func NewEncryptedFile(path string, passphrase func(confirm bool) ([]byte, error)) *EncryptedFile {
+	return &EncryptedFile{path: path, passphrase: passphrase}
+}
+
+// Get 读取密钥
+func (f *EncryptedFile) Get(name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(); err != nil {
+		return "", err
+	}
+	v, ok := f.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("加密文件中不存在密钥: %s", name)
+	}
+	return v, nil
+}
+
+// Set 写入密钥，文件不存在时新建
+func (f *EncryptedFile) Set(name, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(); err != nil {
+		return err
+	}
+	f.secrets[name] = value
+	return f.save()
+}
+
+// load 解密并读取全部密钥，已读取时直接返回
+func (f *EncryptedFile) load() error {
+	if f.secrets != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		// 新建文件
+		pass, err := f.passphrase(true)
+		if err != nil {
+			return err
+		}
+		f.salt = make([]byte, 16)
+		if _, err := rand.Read(f.salt); err != nil {
+			return err
+		}
+		if f.key, err = deriveKey(pass, f.salt); err != nil {
+			return err
+		}
+		f.secrets = map[string]string{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取加密密钥文件失败: %v", err)
+	}
+
+	var payload encryptedPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("加密密钥文件格式错误: %v", err)
+	}
+
+	pass, err := f.passphrase(false)
+	if err != nil {
+		return err
+	}
+	key, err := deriveKey(pass, payload.Salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, payload.Nonce, payload.Data, nil)
+	if err != nil {
+		return fmt.Errorf("解密失败，口令错误或文件已损坏")
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return fmt.Errorf("加密密钥文件内容错误: %v", err)
+	}
+	f.secrets, f.key, f.salt = secrets, key, payload.Salt
+	return nil
+}
+
+// save 加密并以 0600 权限写入文件
+func (f *EncryptedFile) save() error {
+	plain, err := json.Marshal(f.secrets)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(f.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(encryptedPayload{
+		Version: 1,
+		Salt:    f.salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plain, nil),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入加密密钥文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入加密密钥文件失败: %v", err)
+	}
+	return nil
+}
+
+// deriveKey 由口令派生加密密钥
+func deriveKey(pass, salt []byte) ([]byte, error) {
+	if len(pass) == 0 {
+		return nil, fmt.Errorf("口令不能为空")
+	}
+	return scrypt.Key(pass, salt, scryptN, scryptR, scryptP, scryptKeyLen)
+}
+
+// newGCM 创建 AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// PromptPassphrase 获取加密文件口令，优先读取环境变量，否则在终端中输入
+func PromptPassphrase(confirm bool) ([]byte, error) {
+	if pass := os.Getenv(PassphraseEnv); pass != "" {
+		return []byte(pass), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("需要加密文件口令，请设置环境变量 %s", PassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "🔐 加密文件口令: ")
+	pass, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "🔐 再次输入口令: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if string(again) != string(pass) {
+			return nil, fmt.Errorf("两次输入的口令不一致")
+		}
+	}
+	return pass, nil
+}
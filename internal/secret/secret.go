@@ -0,0 +1,190 @@
+package secret
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+)
+
+// 密钥引用前缀
+const (
+	PrefixEnv     = "env:"
+	PrefixCmd     = "cmd:"
+	PrefixKeyring = "keyring:"
+	PrefixFile    = "file:"
+)
+
+// 密钥存储后端
+const (
+	BackendKeyring = "keyring"
+	BackendFile    = "file"
+	BackendPlain   = "plain"
+)
+
+// KeyringService 系统密钥环中使用的服务名
+const KeyringService = "qu"
+
+// Resolver 解析配置中的密钥引用，解析结果会被缓存，避免重复执行命令或输入口令
+type Resolver struct {
+	// File 加密密钥文件
+	File *EncryptedFile
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewResolver 创建密钥解析器，filePath 为加密密钥文件路径
+func NewResolver(filePath string) *Resolver {
+	return &Resolver{File: NewEncryptedFile(filePath, PromptPassphrase)}
+}
+
+// IsReference 判断配置值是否为密钥引用
+func IsReference(value string) bool {
+	for _, prefix := range []string{PrefixEnv, PrefixCmd, PrefixKeyring, PrefixFile} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe 描述配置值的来源
+func Describe(value string) string {
+	switch {
+	case value == "":
+		return "未设置"
+	case strings.HasPrefix(value, PrefixEnv):
+		return "环境变量 " + strings.TrimPrefix(value, PrefixEnv)
+	case strings.HasPrefix(value, PrefixCmd):
+		return "命令 `" + strings.TrimSpace(strings.TrimPrefix(value, PrefixCmd)) + "`"
+	case strings.HasPrefix(value, PrefixKeyring):
+		return "系统密钥环 (" + strings.TrimPrefix(value, PrefixKeyring) + ")"
+	case strings.HasPrefix(value, PrefixFile):
+		return "加密文件 (" + strings.TrimPrefix(value, PrefixFile) + ")"
+	}
+	return "配置文件（明文）"
+}
+
+// Resolve 解析配置值，非引用的值原样返回
+func (r *Resolver) Resolve(value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if v, ok := r.cache[value]; ok {
+		return v, nil
+	}
+
+	v, err := r.resolve(value)
+	if err != nil {
+		return "", err
+	}
+	if r.cache == nil {
+		r.cache = map[string]string{}
+	}
+	r.cache[value] = v
+	return v, nil
+}
+
+// resolve 按引用类型读取密钥
+func (r *Resolver) resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, PrefixEnv):
+		name := strings.TrimPrefix(value, PrefixEnv)
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			return "", fmt.Errorf("环境变量 %s 未设置", name)
+		}
+		return v, nil
+
+	case strings.HasPrefix(value, PrefixCmd):
+		return runCommand(strings.TrimSpace(strings.TrimPrefix(value, PrefixCmd)))
+
+	case strings.HasPrefix(value, PrefixKeyring):
+		name := strings.TrimPrefix(value, PrefixKeyring)
+		v, err := keyring.Get(KeyringService, name)
+		if err != nil {
+			return "", fmt.Errorf("读取系统密钥环失败 (%s): %v", name, err)
+		}
+		return v, nil
+
+	case strings.HasPrefix(value, PrefixFile):
+		if r.File == nil {
+			return "", fmt.Errorf("未配置加密密钥文件")
+		}
+		return r.File.Get(strings.TrimPrefix(value, PrefixFile))
+	}
+	return value, nil
+}
+
+// Store 将密钥保存到指定后端，返回写入配置文件的引用
+func (r *Resolver) Store(backend, name, value string) (string, error) {
+	var ref string
+	switch backend {
+	case BackendPlain, "":
+		return value, nil
+	case BackendKeyring:
+		if err := keyring.Set(KeyringService, name, value); err != nil {
+			return "", fmt.Errorf("写入系统密钥环失败: %v", err)
+		}
+		ref = PrefixKeyring + name
+	case BackendFile:
+		if r.File == nil {
+			return "", fmt.Errorf("未配置加密密钥文件")
+		}
+		if err := r.File.Set(name, value); err != nil {
+			return "", err
+		}
+		ref = PrefixFile + name
+	default:
+		return "", fmt.Errorf("未知的密钥存储方式: %s（可选 keyring、file、plain）", backend)
+	}
+
+	r.mu.Lock()
+	if r.cache == nil {
+		r.cache = map[string]string{}
+	}
+	r.cache[ref] = value
+	r.mu.Unlock()
+	return ref, nil
+}
+
+// KeyringAvailable 检测系统密钥环是否可用
+func KeyringAvailable() bool {
+	_, err := keyring.Get(KeyringService, "qu-availability-check")
+	return err == nil || err == keyring.ErrNotFound
+}
+
+// runCommand 执行命令并返回输出的第一行（兼容 pass 等工具的输出格式）
+func runCommand(command string) (string, error) {
+	if command == "" {
+		return "", fmt.Errorf("密钥命令为空")
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("执行密钥命令失败 (%s): %v", command, err)
+	}
+
+	line, _, _ := strings.Cut(string(out), "\n")
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", fmt.Errorf("密钥命令没有输出 (%s)", command)
+	}
+	return line, nil
+}
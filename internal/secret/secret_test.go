@@ -0,0 +1,120 @@
+package secret
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+// fixedPassphrase 返回固定口令的获取函数
+func fixedPassphrase(pass string) func(bool) ([]byte, error) {
+	return func(bool) ([]byte, error) { return []byte(pass), nil }
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("QU_TEST_SECRET", "from-env")
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		wantErr  bool
+		posix    bool
+	}{
+		{"Plain", "plain-secret", "plain-secret", false, false},
+		{"Empty", "", "", false, false},
+		{"Env", "env:QU_TEST_SECRET", "from-env", false, false},
+		{"Missing env", "env:QU_TEST_SECRET_MISSING", "", true, false},
+		{"Command first line", "cmd:printf 'from-cmd\\nlogin: me\\n'", "from-cmd", false, true},
+		{"Failing command", "cmd:exit 1", "", true, true},
+	}
+
+	r := NewResolver(filepath.Join(t.TempDir(), "secrets.enc"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.posix && runtime.GOOS == "windows" {
+				t.Skip("requires a POSIX shell")
+			}
+			got, err := r.Resolve(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("Resolve(%q) = %q, expected %q", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := map[string]string{
+		"":                    "未设置",
+		"abc":                 "配置文件（明文）",
+		"env:QINIU_SK":        "环境变量 QINIU_SK",
+		"cmd:pass show qiniu": "命令 `pass show qiniu`",
+		"keyring:default/sk":  "系统密钥环 (default/sk)",
+		"file:default/sk":     "加密文件 (default/sk)",
+	}
+	for value, expected := range tests {
+		if got := Describe(value); got != expected {
+			t.Errorf("Describe(%q) = %q, expected %q", value, got, expected)
+		}
+	}
+}
+
+func TestKeyringStore(t *testing.T) {
+	keyring.MockInit()
+
+	r := NewResolver("")
+	ref, err := r.Store(BackendKeyring, "default/secret_key", "sk-value")
+	if err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if ref != "keyring:default/secret_key" {
+		t.Errorf("Store() ref = %q", ref)
+	}
+
+	// 新的解析器不使用缓存，直接读取密钥环
+	got, err := NewResolver("").Resolve(ref)
+	if err != nil || got != "sk-value" {
+		t.Errorf("Resolve(%q) = %q, %v", ref, got, err)
+	}
+}
+
+func TestEncryptedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+
+	r := &Resolver{File: NewEncryptedFile(path, fixedPassphrase("correct horse"))}
+	ref, err := r.Store(BackendFile, "blog/secret_key", "sk-blog")
+	if err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if ref != "file:blog/secret_key" {
+		t.Errorf("Store() ref = %q", ref)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("secret file mode = %v, expected 0600", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "sk-blog") {
+		t.Error("secret file contains plaintext secret")
+	}
+
+	got, err := NewEncryptedFile(path, fixedPassphrase("correct horse")).Get("blog/secret_key")
+	if err != nil || got != "sk-blog" {
+		t.Errorf("Get() = %q, %v", got, err)
+	}
+
+	if _, err := NewEncryptedFile(path, fixedPassphrase("wrong")).Get("blog/secret_key"); err == nil {
+		t.Error("expected error for wrong passphrase")
+	}
+}
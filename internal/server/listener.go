@@ -0,0 +1,100 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"qiniu-uploader/internal/config"
+)
+
+// listenFDsStart systemd 套接字激活传入的第一个文件描述符
+const listenFDsStart = 3
+
+// Listen 按配置创建监听：listen 为 unix:<路径> 时监听Unix套接字，为 systemd 时使用套接字激活传入的监听，
+// 为空时监听 host:port
+func Listen(cfg *config.Config) (net.Listener, error) {
+	switch {
+	case cfg.Listen == "systemd":
+		return systemdListener()
+	case strings.HasPrefix(cfg.Listen, "unix:"):
+		return unixListener(strings.TrimPrefix(cfg.Listen, "unix:"))
+	case cfg.Listen != "":
+		return nil, fmt.Errorf("不支持的监听方式: %s", cfg.Listen)
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("监听 %s 失败: %v", addr, err)
+	}
+	return ln, nil
+}
+
+// unixListener 监听Unix套接字，删除上次运行遗留的套接字文件，关闭监听时自动删除
+func unixListener(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s 已存在且不是套接字文件", path)
+		}
+		// 仍有服务在监听时不删除
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s 已被其他进程监听", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("删除遗留的套接字文件失败: %v", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("监听 %s 失败: %v", path, err)
+	}
+	// 只允许同组用户（如反向代理）连接
+	if err := os.Chmod(path, 0660); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("设置套接字权限失败: %v", err)
+	}
+	return ln, nil
+}
+
+// systemdListener 使用 systemd 套接字激活传入的第一个监听，参考 sd_listen_fds(3)
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New("未通过 systemd 套接字激活启动（LISTEN_PID 未设置或不是当前进程）")
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, errors.New("systemd 未传入套接字（LISTEN_FDS 为空）")
+	}
+
+	// 子进程不继承激活的套接字
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(uintptr(listenFDsStart), "systemd-socket")
+	if f == nil {
+		return nil, errors.New("systemd 传入的套接字无效")
+	}
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("使用 systemd 传入的套接字失败: %v", err)
+	}
+	return ln, nil
+}
+
+// displayAddr 返回监听地址的显示形式
+func displayAddr(ln net.Listener) string {
+	addr := ln.Addr()
+	if addr.Network() == "tcp" {
+		return "http://" + addr.String()
+	}
+	return addr.Network() + ":" + addr.String()
+}
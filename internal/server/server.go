@@ -1,43 +1,121 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/history"
 	"qiniu-uploader/internal/routes"
+	"qiniu-uploader/internal/token"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Server 服务器结构体
 type Server struct {
-	config *config.Config
-	router *gin.Engine
+	config  *config.Config
+	router  *gin.Engine
+	tokens  *token.Store
+	history *history.Store
 }
 
-// NewServer 创建新的服务器实例
-func NewServer(cfg *config.Config) *Server {
+// NewServer 创建新的服务器实例，tokens 用于API令牌认证，hist 记录浏览器直传的文件（可为nil）
+func NewServer(cfg *config.Config, tokens *token.Store, hist *history.Store) *Server {
 	// 设置Gin模式
 	gin.SetMode(cfg.GinMode)
 
 	router := gin.Default()
 
+	// 默认不信任代理转发的客户端IP
+	_ = router.SetTrustedProxies(nil)
+
 	return &Server{
-		config: cfg,
-		router: router,
+		config:  cfg,
+		router:  router,
+		tokens:  tokens,
+		history: hist,
 	}
 }
 
 // Setup 设置服务器
 func (s *Server) Setup() {
 	// 设置路由
-	routes.SetupRoutes(s.router, s.config)
+	routes.SetupRoutes(s.router, s.config, s.tokens, s.history)
 }
 
-// Start 启动服务器
+// readHeaderTimeout 读取请求头的超时时间，避免慢速连接长期占用
+const readHeaderTimeout = 10 * time.Second
+
+// Start 启动服务器，收到 SIGINT 或 SIGTERM 后停止接受新请求，等待进行中的上传完成后退出
 func (s *Server) Start() error {
-	addr := fmt.Sprintf(":%d", s.config.Port)
-	log.Printf("服务器启动在 http://localhost%s", addr)
-	return s.router.Run(addr)
-}
\ No newline at end of file
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return s.Run(ctx)
+}
+
+// Run 按配置监听并提供服务，直到 ctx 取消。取消后在 shutdown_timeout 内等待进行中的请求完成，
+// 超时后强制关闭剩余连接并返回错误
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := Listen(s.config)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve 在指定的监听上提供服务，直到 ctx 取消，行为与 Run 相同
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := s.newHTTPServer()
+	log.Printf("服务器启动在 %s", displayAddr(ln))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := seconds(s.config.ShutdownTimeout)
+	log.Printf("收到退出信号，停止接受新请求，等待进行中的上传完成（最长 %s）", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("等待进行中的请求超时，已强制关闭: %v", err)
+	}
+	log.Printf("服务器已停止")
+	return nil
+}
+
+// newHTTPServer 按配置的超时时间和请求头上限创建 http.Server
+func (s *Server) newHTTPServer() *http.Server {
+	srv := &http.Server{
+		Handler:           s.router,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       seconds(s.config.ReadTimeout),
+		WriteTimeout:      seconds(s.config.WriteTimeout),
+		IdleTimeout:       seconds(s.config.IdleTimeout),
+		MaxHeaderBytes:    s.config.MaxHeaderBytes,
+	}
+	if srv.ReadTimeout > 0 && srv.ReadTimeout < srv.ReadHeaderTimeout {
+		srv.ReadHeaderTimeout = srv.ReadTimeout
+	}
+	return srv
+}
+
+// seconds 将配置中的秒数转换为时间间隔
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
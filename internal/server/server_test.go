@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"qiniu-uploader/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestServer 创建带有阻塞路由的服务器，请求到达后通知 started，release 关闭后返回
+func newTestServer(shutdownTimeout int) (*Server, chan struct{}, chan struct{}) {
+	cfg := config.Default()
+	cfg.GinMode = gin.TestMode
+	cfg.ShutdownTimeout = shutdownTimeout
+	s := NewServer(cfg, nil, nil)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	s.router.POST("/api/upload", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "uploaded")
+	})
+	return s, started, release
+}
+
+func TestServeDrainsActiveRequests(t *testing.T) {
+	s, started, release := newTestServer(5)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, ln) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Post("http://"+ln.Addr().String()+"/api/upload", "text/plain", nil)
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{string(body), err}
+	}()
+
+	<-started
+	cancel()
+	// 停止接受新连接后，进行中的请求仍然完成
+	time.Sleep(50 * time.Millisecond)
+	if conn, err := net.Dial("tcp", ln.Addr().String()); err == nil {
+		conn.Close()
+		t.Error("expected new connections to be refused during shutdown")
+	}
+	close(release)
+
+	if r := <-responses; r.err != nil || r.body != "uploaded" {
+		t.Errorf("in-flight request = %q, %v; expected uploaded", r.body, r.err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error: %v", err)
+	}
+}
+
+func TestServeShutdownTimeout(t *testing.T) {
+	s, started, release := newTestServer(0)
+	defer close(release)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, ln) }()
+
+	go http.Post("http://"+ln.Addr().String()+"/api/upload", "text/plain", nil)
+	<-started
+	cancel()
+	if err := <-done; err == nil {
+		t.Error("expected error when requests are still active after the deadline")
+	}
+}
+
+func TestNewHTTPServer(t *testing.T) {
+	cfg := config.Default()
+	cfg.ReadTimeout = 5
+	cfg.WriteTimeout = 0
+	s := NewServer(cfg, nil, nil)
+
+	srv := s.newHTTPServer()
+	if srv.ReadTimeout != 5*time.Second || srv.ReadHeaderTimeout != 5*time.Second {
+		t.Errorf("ReadTimeout = %s, ReadHeaderTimeout = %s, expected 5s", srv.ReadTimeout, srv.ReadHeaderTimeout)
+	}
+	if srv.WriteTimeout != 0 || srv.IdleTimeout != 120*time.Second || srv.MaxHeaderBytes != 1<<20 {
+		t.Errorf("WriteTimeout = %s, IdleTimeout = %s, MaxHeaderBytes = %d", srv.WriteTimeout, srv.IdleTimeout, srv.MaxHeaderBytes)
+	}
+}
+
+func TestUnixListener(t *testing.T) {
+	dir := t.TempDir()
+
+	// 上次运行遗留的套接字文件会被替换
+	path := filepath.Join(dir, "qu.sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := unixListener(path)
+	if err != nil {
+		t.Fatalf("unixListener() with stale socket error: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0660 {
+		t.Errorf("socket mode = %v, %v; expected 0660", info.Mode().Perm(), err)
+	}
+	if got := displayAddr(ln); got != "unix:"+path {
+		t.Errorf("displayAddr() = %q", got)
+	}
+
+	// 正在监听的套接字和普通文件不会被删除
+	if _, err := unixListener(path); err == nil {
+		t.Error("expected error for socket in use")
+	}
+	ln.Close()
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unixListener(file); err == nil {
+		t.Error("expected error for regular file")
+	}
+}
+
+func TestSystemdListenerRequiresActivation(t *testing.T) {
+	tests := []struct {
+		name string
+		pid  string
+		fds  string
+	}{
+		{"Not activated", "", ""},
+		{"Other process", strconv.Itoa(os.Getpid() + 1), "1"},
+		{"No sockets", strconv.Itoa(os.Getpid()), "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LISTEN_PID", tt.pid)
+			t.Setenv("LISTEN_FDS", tt.fds)
+			if _, err := systemdListener(); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
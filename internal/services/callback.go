@@ -0,0 +1,38 @@
+package services
+
+import (
+	"crypto/hmac"
+	"net/http"
+	"strings"
+)
+
+// 七牛云上传回调的请求体，表单格式的请求体包含在签名中
+const (
+	callbackBody     = "key=$(key)&hash=$(etag)&fsize=$(fsize)&mime_type=$(mimeType)&filename=$(fname)"
+	callbackBodyType = "application/x-www-form-urlencoded"
+)
+
+// VerifyCallback 使用配置的密钥验证七牛云回调请求的 Authorization 签名，支持 QBox 和 Qiniu 两种格式
+func (s *QiniuService) VerifyCallback(req *http.Request) bool {
+	// 只有表单请求体包含在签名中，其他类型的请求体可能被篡改
+	if req.Header.Get("Content-Type") != callbackBodyType {
+		return false
+	}
+	auth := req.Header.Get("Authorization")
+
+	var (
+		expected string
+		err      error
+	)
+	switch {
+	case strings.HasPrefix(auth, "QBox "):
+		expected, err = s.mac.SignRequest(req)
+		expected = "QBox " + expected
+	case strings.HasPrefix(auth, "Qiniu "):
+		expected, err = s.mac.SignRequestV2(req)
+		expected = "Qiniu " + expected
+	default:
+		return false
+	}
+	return err == nil && hmac.Equal([]byte(auth), []byte(expected))
+}
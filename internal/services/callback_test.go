@@ -0,0 +1,77 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"qiniu-uploader/internal/config"
+
+	"github.com/qiniu/go-sdk/v7/auth/qbox"
+)
+
+func TestVerifyCallback(t *testing.T) {
+	cfg := config.Default()
+	cfg.QiniuAccessKey = "ak"
+	cfg.QiniuSecretKey = "sk"
+	cfg.QiniuBucket = "assets"
+	svc := NewQiniuService(cfg)
+
+	const body = "key=a.png&hash=Fh&fsize=10&mime_type=image%2Fpng&filename=a.png"
+	newRequest := func(contentType, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/callback/qiniu", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+	sign := func(mac *qbox.Mac, req *http.Request, v2 bool) string {
+		if v2 {
+			token, err := mac.SignRequestV2(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return "Qiniu " + token
+		}
+		token, err := mac.SignRequest(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "QBox " + token
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		v2       bool
+		tamper   bool
+		formType bool
+		expected bool
+	}{
+		{"qbox", "sk", false, false, true, true},
+		{"qiniu", "sk", true, false, true, true},
+		{"wrong secret", "other", false, false, true, false},
+		{"tampered body", "sk", false, true, true, false},
+		{"unsigned content type", "sk", false, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType := callbackBodyType
+			if !tt.formType {
+				contentType = "text/plain"
+			}
+			req := newRequest(contentType, body)
+			signed := req
+			if tt.tamper {
+				req = newRequest(contentType, strings.Replace(body, "fsize=10", "fsize=1", 1))
+			}
+			req.Header.Set("Authorization", sign(qbox.NewMac("ak", tt.secret), signed, tt.v2))
+			if got := svc.VerifyCallback(req); got != tt.expected {
+				t.Errorf("VerifyCallback() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	if svc.VerifyCallback(newRequest(callbackBodyType, body)) {
+		t.Error("expected request without Authorization to be rejected")
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"qiniu-uploader/internal/metrics"
+	"qiniu-uploader/internal/models"
+	"qiniu-uploader/internal/utils"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+// DirectUploadTTL 浏览器直传凭证的有效期
+const DirectUploadTTL = 10 * time.Minute
+
+// ErrNotUploaded 直传的文件在存储空间中不存在
+var ErrNotUploaded = errors.New("文件尚未上传到七牛云")
+
+// directReturnBody 直传成功后七牛云返回给浏览器的内容
+const directReturnBody = `{"key":"$(key)","hash":"$(etag)","fsize":$(fsize),"mime_type":"$(mimeType)"}`
+
+// getRegion 查询存储空间所在区域，便于测试时替换
+var getRegion = storage.GetRegion
+
+// NewDirectUpload 按key模板生成存储key，并签发只能上传到该key的短期凭证，
+// 凭证中限制文件大小和MIME类型，由七牛云在上传时检查
+func (s *QiniuService) NewDirectUpload(filename string) (*models.DirectUpload, error) {
+	uploadURL, err := s.uploadURL()
+	if err != nil {
+		return nil, err
+	}
+
+	key := s.generateFileKey(filename)
+	policy := storage.PutPolicy{
+		Scope:      s.bucket + ":" + key,
+		Expires:    uint64(DirectUploadTTL / time.Second),
+		InsertOnly: 1,
+		FsizeLimit: s.config.MaxFileSize,
+		MimeLimit:  strings.Join(s.config.AllowedTypes, ";"),
+		// 忽略浏览器声明的类型，按文件内容侦测
+		DetectMime: 1,
+		ReturnBody: directReturnBody,
+	}
+	// 配置回调地址时，上传完成后由七牛云通知服务端，返回给浏览器的是回调的响应
+	if s.config.CallbackURL != "" {
+		policy.CallbackURL = s.config.CallbackURL
+		policy.CallbackBody = callbackBody
+		policy.CallbackBodyType = callbackBodyType
+	}
+
+	return &models.DirectUpload{
+		Token:        policy.UploadToken(s.mac),
+		Key:          key,
+		UploadURL:    uploadURL,
+		URL:          s.generateFileURL(key),
+		ExpiresAt:    time.Now().Add(DirectUploadTTL).UTC().Truncate(time.Second),
+		MaxFileSize:  s.config.MaxFileSize,
+		AllowedTypes: s.config.AllowedTypes,
+	}, nil
+}
+
+// CompleteDirectUpload 确认直传的文件已存在并符合限制，不符合时删除文件
+func (s *QiniuService) CompleteDirectUpload(key string) (*models.UploadResponse, error) {
+	info, err := s.bucketMgr.Stat(s.bucket, key)
+	if err != nil {
+		metrics.ObserveQiniuError("stat", err)
+		if isNotFound(err) {
+			return nil, ErrNotUploaded
+		}
+		return nil, fmt.Errorf("获取文件信息失败: %v", err)
+	}
+
+	return s.FinishUpload(key, info.Hash, info.Fsize, info.MimeType)
+}
+
+// FinishUpload 检查直接上传到七牛云的文件是否符合大小和类型限制，不符合时删除文件
+func (s *QiniuService) FinishUpload(key, hash string, size int64, mimeType string) (*models.UploadResponse, error) {
+	if err := utils.ValidateUpload(size, mimeType, s.config.MaxFileSize, s.config.AllowedTypes); err != nil {
+		if delErr := s.bucketMgr.Delete(s.bucket, key); delErr != nil {
+			metrics.ObserveQiniuError("delete", delErr)
+			return nil, fmt.Errorf("%v，删除文件失败: %v", err, delErr)
+		}
+		return nil, err
+	}
+
+	metrics.UploadBytes.Add(float64(size), "direct")
+
+	response := &models.UploadResponse{
+		Success: true,
+		Message: "上传成功",
+	}
+	response.Data.Key = key
+	response.Data.Hash = hash
+	response.Data.URL = s.generateFileURL(key)
+	response.Data.FileSize = size
+	response.Data.OriginalSize = size
+	response.Data.MimeType = mimeType
+	return response, nil
+}
+
+// uploadURL 浏览器直传使用的上传地址，按存储空间所在区域选择
+func (s *QiniuService) uploadURL() (string, error) {
+	region, err := s.bucketRegion()
+	if err != nil {
+		return "", err
+	}
+	if len(region.CdnUpHosts) > 0 {
+		return "https://" + region.CdnUpHosts[0], nil
+	}
+	if len(region.SrcUpHosts) > 0 {
+		return "https://" + region.SrcUpHosts[0], nil
+	}
+	return "", fmt.Errorf("存储空间 %s 所在区域没有可用的上传地址", s.bucket)
+}
+
+// bucketRegion 查询并缓存存储空间所在区域，查询失败时下次重试
+func (s *QiniuService) bucketRegion() (*storage.Region, error) {
+	s.regionMu.Lock()
+	defer s.regionMu.Unlock()
+
+	if s.region != nil {
+		return s.region, nil
+	}
+	region, err := getRegion(s.mac.AccessKey, s.bucket)
+	if err != nil {
+		return nil, fmt.Errorf("查询存储空间所在区域失败: %v", err)
+	}
+	s.region = region
+	return region, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"qiniu-uploader/internal/config"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+func TestNewDirectUpload(t *testing.T) {
+	cfg := config.Default()
+	cfg.QiniuAccessKey = "ak"
+	cfg.QiniuSecretKey = "sk"
+	cfg.QiniuBucket = "assets"
+	cfg.QiniuDomain = "cdn.example.com"
+	cfg.KeyPrefix = "web/"
+	cfg.KeyTemplate = "{prefix}{name}{ext}"
+	cfg.AllowedTypes = []string{"image/png", "image/jpeg"}
+
+	// 存储空间位于华南，上传地址按查询到的区域选择
+	original := getRegion
+	defer func() { getRegion = original }()
+	var lookups int
+	getRegion = func(ak, bucket string) (*storage.Region, error) {
+		lookups++
+		if ak != "ak" || bucket != "assets" {
+			t.Errorf("getRegion(%q, %q), expected ak and assets", ak, bucket)
+		}
+		region := storage.ZoneHuanan
+		return &region, nil
+	}
+
+	service := NewQiniuService(cfg)
+	upload, err := service.NewDirectUpload("photo.png")
+	if err != nil {
+		t.Fatalf("NewDirectUpload() error: %v", err)
+	}
+	if _, err := service.NewDirectUpload("photo.png"); err != nil || lookups != 1 {
+		t.Errorf("second NewDirectUpload() error = %v, lookups = %d, expected cached region", err, lookups)
+	}
+	if upload.Key != "web/photo.png" {
+		t.Errorf("Key = %q, expected web/photo.png", upload.Key)
+	}
+	if upload.URL != "https://cdn.example.com/web/photo.png" || upload.UploadURL != "https://upload-z2.qiniup.com" {
+		t.Errorf("URL = %q, UploadURL = %q", upload.URL, upload.UploadURL)
+	}
+
+	parts := strings.Split(upload.Token, ":")
+	if len(parts) != 3 || parts[0] != "ak" {
+		t.Fatalf("unexpected upload token %q", upload.Token)
+	}
+	data, err := base64.URLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var policy storage.PutPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		t.Fatal(err)
+	}
+
+	if policy.Scope != "assets:web/photo.png" {
+		t.Errorf("Scope = %q, expected token bound to key", policy.Scope)
+	}
+	if policy.FsizeLimit != cfg.MaxFileSize || policy.MimeLimit != "image/png;image/jpeg" || policy.InsertOnly != 1 {
+		t.Errorf("policy limits = %d %q insertOnly=%d", policy.FsizeLimit, policy.MimeLimit, policy.InsertOnly)
+	}
+	deadline := time.Unix(int64(policy.Expires), 0)
+	if d := time.Until(deadline); d <= 0 || d > DirectUploadTTL {
+		t.Errorf("deadline in %v, expected within %v", d, DirectUploadTTL)
+	}
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"qiniu-uploader/internal/metrics"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+// CheckReady 列举存储空间中的一个文件，检查七牛云是否可访问、密钥是否有效、存储空间是否存在
+func (s *QiniuService) CheckReady(ctx context.Context) error {
+	if s.bucket == "" {
+		return errors.New("未设置存储空间")
+	}
+	_, _, err := s.bucketMgr.ListFilesWithContext(ctx, s.bucket, storage.ListInputOptionsLimit(1))
+	if err != nil {
+		metrics.ObserveQiniuError("ready", err)
+		return fmt.Errorf("访问存储空间 %s 失败（%s）: %v", s.bucket, metrics.ErrorClass(err), err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"qiniu-uploader/internal/metrics"
+	"qiniu-uploader/internal/models"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+// MaxBatchDelete 单次批量删除的最大文件数（七牛云批量操作的上限）
+const MaxBatchDelete = 1000
+
+var (
+	// ErrNotFound 文件不存在
+	ErrNotFound = errors.New("文件不存在")
+	// ErrKeyExists 重命名的目标key已存在
+	ErrKeyExists = errors.New("目标文件已存在")
+)
+
+// 七牛云资源管理接口的错误码
+const (
+	codeNotFound  = 612
+	codeKeyExists = 614
+)
+
+// GetImage 获取文件详情，包括自定义元数据
+func (s *QiniuService) GetImage(key string) (*models.ImageInfo, error) {
+	info, err := s.bucketMgr.Stat(s.bucket, key)
+	if err != nil {
+		metrics.ObserveQiniuError("stat", err)
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("获取文件信息失败: %v", err)
+	}
+
+	image := s.newImageInfo(key, info.Hash, info.Fsize, info.MimeType, info.PutTime)
+	image.Metadata = info.MetaData
+	return &image, nil
+}
+
+// DeleteImage 删除文件
+func (s *QiniuService) DeleteImage(key string) error {
+	if err := s.bucketMgr.Delete(s.bucket, key); err != nil {
+		metrics.ObserveQiniuError("delete", err)
+		if isNotFound(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("删除文件失败: %v", err)
+	}
+	return nil
+}
+
+// UpdateImage 按需重命名文件后修改MIME类型和元数据，返回修改后的文件详情。
+// 先重命名，目标已存在时不会改动原文件；重命名成功但修改信息失败时返回 *PartialUpdateError
+func (s *QiniuService) UpdateImage(key string, req models.ImageUpdateRequest) (*models.ImageInfo, error) {
+	renamed := false
+	if req.Key != "" && req.Key != key {
+		if err := s.bucketMgr.Move(s.bucket, key, s.bucket, req.Key, false); err != nil {
+			metrics.ObserveQiniuError("move", err)
+			switch errorCode(err) {
+			case codeNotFound:
+				return nil, ErrNotFound
+			case codeKeyExists:
+				return nil, ErrKeyExists
+			}
+			return nil, fmt.Errorf("重命名文件失败: %v", err)
+		}
+		key = req.Key
+		renamed = true
+	}
+
+	if req.MimeType != "" || len(req.Metadata) > 0 {
+		if err := s.bucketMgr.ChangeMimeAndMeta(s.bucket, key, req.MimeType, req.Metadata); err != nil {
+			metrics.ObserveQiniuError("change_meta", err)
+			if renamed {
+				return nil, &PartialUpdateError{Key: key, Err: err}
+			}
+			if isNotFound(err) {
+				return nil, ErrNotFound
+			}
+			return nil, fmt.Errorf("修改文件信息失败: %v", err)
+		}
+	}
+
+	return s.GetImage(key)
+}
+
+// PartialUpdateError 文件已重命名，但修改MIME类型或元数据失败
+type PartialUpdateError struct {
+	Key string
+	Err error
+}
+
+func (e *PartialUpdateError) Error() string {
+	return fmt.Sprintf("文件已重命名为 %s，但修改文件信息失败: %v", e.Key, e.Err)
+}
+
+func (e *PartialUpdateError) Unwrap() error {
+	return e.Err
+}
+
+// BatchDelete 批量删除文件，返回每个文件的删除结果
+func (s *QiniuService) BatchDelete(keys []string) ([]models.BatchDeleteItem, error) {
+	if len(keys) > MaxBatchDelete {
+		return nil, fmt.Errorf("单次最多删除 %d 个文件", MaxBatchDelete)
+	}
+
+	ops := make([]string, len(keys))
+	for i, key := range keys {
+		ops[i] = storage.URIDelete(s.bucket, key)
+	}
+	rets, err := s.bucketMgr.Batch(ops)
+	if err != nil && len(rets) != len(keys) {
+		metrics.ObserveQiniuError("batch", err)
+		return nil, fmt.Errorf("批量删除失败: %v", err)
+	}
+
+	return batchDeleteItems(keys, rets), nil
+}
+
+// batchDeleteItems 按请求顺序生成每个文件的结果，七牛云未返回结果的文件记为失败
+func batchDeleteItems(keys []string, rets []storage.BatchOpRet) []models.BatchDeleteItem {
+	items := make([]models.BatchDeleteItem, len(keys))
+	for i, key := range keys {
+		items[i] = models.BatchDeleteItem{
+			Key:     key,
+			Status:  http.StatusBadGateway,
+			Message: "七牛云未返回该文件的删除结果",
+		}
+	}
+
+	for i := 0; i < min(len(rets), len(keys)); i++ {
+		ret := rets[i]
+		item := &items[i]
+		item.Success = ret.Code == http.StatusOK
+		switch {
+		case item.Success:
+			item.Status, item.Message = http.StatusOK, ""
+		case ret.Code == codeNotFound:
+			item.Status, item.Message = http.StatusNotFound, ErrNotFound.Error()
+		case ret.Code >= 500:
+			// 5xx 和其他 6xx 为七牛云服务端的错误
+			item.Status = http.StatusBadGateway
+			item.Message = fmt.Sprintf("删除失败 (%d): %s", ret.Code, ret.Data.Error)
+		default:
+			item.Status = http.StatusInternalServerError
+			item.Message = fmt.Sprintf("删除失败 (%d): %s", ret.Code, ret.Data.Error)
+		}
+	}
+	return items
+}
+
+// isNotFound 判断是否为文件不存在的错误
+func isNotFound(err error) bool {
+	return errorCode(err) == codeNotFound
+}
+
+// errorCode 返回七牛云接口的错误码
+func errorCode(err error) int {
+	if errInfo, ok := err.(*storage.ErrorInfo); ok {
+		return errInfo.Code
+	}
+	return 0
+}
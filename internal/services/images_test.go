@@ -0,0 +1,49 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+func TestBatchDeleteItems(t *testing.T) {
+	ret := func(code int) storage.BatchOpRet {
+		return storage.BatchOpRet{Code: code}
+	}
+
+	tests := []struct {
+		name string
+		keys []string
+		rets []storage.BatchOpRet
+		want []int
+	}{
+		{"Matched", []string{"a", "b", "c"}, []storage.BatchOpRet{ret(200), ret(codeNotFound), ret(599)}, []int{http.StatusOK, http.StatusNotFound, http.StatusBadGateway}},
+		{"Client error", []string{"a"}, []storage.BatchOpRet{ret(400)}, []int{http.StatusInternalServerError}},
+		{"Fewer rets", []string{"a", "b"}, []storage.BatchOpRet{ret(200)}, []int{http.StatusOK, http.StatusBadGateway}},
+		{"More rets", []string{"a"}, []storage.BatchOpRet{ret(200), ret(200)}, []int{http.StatusOK}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := batchDeleteItems(tt.keys, tt.rets)
+			if len(items) != len(tt.keys) {
+				t.Fatalf("got %d items, want %d", len(items), len(tt.keys))
+			}
+			for i, item := range items {
+				if item.Key != tt.keys[i] {
+					t.Errorf("items[%d].Key = %q, want %q", i, item.Key, tt.keys[i])
+				}
+				if item.Status != tt.want[i] {
+					t.Errorf("items[%d].Status = %d, want %d", i, item.Status, tt.want[i])
+				}
+				if item.Success != (item.Status == http.StatusOK) {
+					t.Errorf("items[%d].Success = %v with status %d", i, item.Success, item.Status)
+				}
+				if !item.Success && item.Message == "" {
+					t.Errorf("items[%d] failed without a message", i)
+				}
+			}
+		})
+	}
+}
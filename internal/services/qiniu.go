@@ -1,51 +1,216 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"qiniu-uploader/internal/config"
+	"qiniu-uploader/internal/metrics"
 	"qiniu-uploader/internal/models"
+	"qiniu-uploader/internal/utils"
+	"qiniu-uploader/pkg/imaging"
+	"qiniu-uploader/pkg/qiniu"
 
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
 	"github.com/qiniu/go-sdk/v7/storage"
 )
 
+// memoryUploadLimit 不超过该大小的文件在内存中处理并使用表单上传，更大的文件写入临时文件后分片上传
+const memoryUploadLimit = 4 << 20
+
 type QiniuService struct {
-	config     *config.Config
-	mac        *qbox.Mac
-	bucket     string
-	uploader   *storage.FormUploader
-	bucketMgr  *storage.BucketManager
+	config    *config.Config
+	mac       *qbox.Mac
+	bucket    string
+	uploader  *storage.FormUploader
+	resumer   *storage.ResumeUploaderV2
+	bucketMgr *storage.BucketManager
+
+	// region 存储空间所在区域，首次直传时查询
+	region   *storage.Region
+	regionMu sync.Mutex
 }
 
 func NewQiniuService(cfg *config.Config) *QiniuService {
 	mac := qbox.NewMac(cfg.QiniuAccessKey, cfg.QiniuSecretKey)
 
-	// 配置上传区域（这里使用华东区域作为默认值）
-	cfg := storage.Config{
-		Zone:          &storage.ZoneHuadong,
+	// 不指定区域，由SDK按存储空间自动检测
+	qiniuCfg := storage.Config{
+		Zone:          nil,
 		UseHTTPS:      true,
 		UseCdnDomains: true,
 	}
 
-	uploader := storage.NewFormUploader(&cfg)
-	bucketMgr := storage.NewBucketManager(mac, &cfg)
+	uploader := storage.NewFormUploader(&qiniuCfg)
+	resumer := storage.NewResumeUploaderV2(&qiniuCfg)
+	bucketMgr := storage.NewBucketManager(mac, &qiniuCfg)
 
 	return &QiniuService{
 		config:    cfg,
 		mac:       mac,
 		bucket:    cfg.QiniuBucket,
 		uploader:  uploader,
+		resumer:   resumer,
 		bucketMgr: bucketMgr,
 	}
 }
 
-// UploadFile 上传文件到七牛云
-func (s *QiniuService) UploadFile(fileData []byte, filename string) (*models.UploadResponse, error) {
+// UploadFile 上传文件到七牛云，opts 为上传前的预处理策略
+func (s *QiniuService) UploadFile(fileData []byte, filename string, opts imaging.Options) (*models.UploadResponse, error) {
+	originalSize := int64(len(fileData))
+
+	// 按预处理策略缩放、压缩并清除元数据
+	var processed *imaging.Result
+	if opts.Enabled() {
+		result, err := imaging.Process(fileData, opts)
+		if err != nil {
+			return nil, fmt.Errorf("图片预处理失败: %v", err)
+		}
+		fileData = result.Data
+		processed = result
+	}
+
+	// 格式转换后扩展名和MIME类型随之变化
+	mimeType := utils.GetMimeTypeFromExtension(filename)
+	putMimeType := ""
+	if processed != nil && processed.Format != "" {
+		mimeType = imaging.FormatMIMEType(processed.Format)
+		if processed.Format != processed.OriginalFormat {
+			filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + imaging.FormatExtension(processed.Format)
+			putMimeType = mimeType
+		}
+	}
+
+	response, err := s.put(bytes.NewReader(fileData), int64(len(fileData)), filename, putMimeType)
+	if err != nil {
+		return nil, err
+	}
+	response.Data.OriginalSize = originalSize
+	response.Data.MimeType = mimeType
+	if processed != nil {
+		response.Data.MetadataRemoved = processed.MetadataRemoved
+		response.Data.GPSRemoved = processed.GPSRemoved
+		if processed.Format != processed.OriginalFormat {
+			response.Data.ConvertedFrom = processed.OriginalFormat
+		}
+		response.Data.ConvertSkipped = processed.ConvertSkipReason
+	}
+
+	return response, nil
+}
+
+// UploadStream 从 r 读取文件并上传，小文件在内存中处理，大文件写入临时文件后分片上传，
+// 内存占用不随文件大小增长（启用缩放、压缩或格式转换时除外）。调用方负责限制 r 的大小
+func (s *QiniuService) UploadStream(r io.Reader, filename string, opts imaging.Options) (*models.UploadResponse, error) {
+	file, err := SpoolFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return s.UploadSpooled(file, filename, opts)
+}
+
+// UploadSpooled 上传已读取的文件
+func (s *QiniuService) UploadSpooled(file *SpooledFile, filename string, opts imaging.Options) (*models.UploadResponse, error) {
+	if file.file == nil {
+		return s.UploadFile(file.data, filename, opts)
+	}
+
+	// 缩放、压缩和格式转换需要完整的图片内容，文件大小由调用方限制
+	if opts.Reencodes() {
+		data, err := os.ReadFile(file.file.Name())
+		if err != nil {
+			return nil, fmt.Errorf("读取临时文件失败: %v", err)
+		}
+		return s.UploadFile(data, filename, opts)
+	}
+
+	// 仅清除元数据时只读取元数据所在的结构，图像数据在上传时从临时文件读取
+	var body uploadBody = file.file
+	size := file.Size
+	var stripped *imaging.StripResult
+	if opts.StripMetadata {
+		result, err := imaging.StripMetadataAt(file.file, file.Size)
+		if err != nil {
+			return nil, fmt.Errorf("图片预处理失败: 清除元数据失败: %v", err)
+		}
+		body, size, stripped = result.Body, result.Body.Size(), result
+	}
+
+	response, err := s.put(body, size, filename, "")
+	if err != nil {
+		return nil, err
+	}
+	response.Data.OriginalSize = file.Size
+	response.Data.MimeType = utils.GetMimeTypeFromExtension(filename)
+	if stripped != nil {
+		response.Data.MetadataRemoved = stripped.Removed
+		response.Data.GPSRemoved = stripped.HadGPS
+	}
+	return response, nil
+}
+
+// SpooledFile 已读取的上传文件，不超过 memoryUploadLimit 时保存在内存中，否则保存在临时文件中
+type SpooledFile struct {
+	Size int64
+	data []byte
+	file *os.File
+}
+
+// SpoolFile 读取 r 的全部内容，调用方负责限制 r 的大小，使用后需调用 Close
+func SpoolFile(r io.Reader) (*SpooledFile, error) {
+	head, err := io.ReadAll(io.LimitReader(r, memoryUploadLimit+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	if len(head) <= memoryUploadLimit {
+		return &SpooledFile{Size: int64(len(head)), data: head}, nil
+	}
+
+	tmp, err := os.CreateTemp("", "qu-upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	file := &SpooledFile{file: tmp}
+	if file.Size, err = io.Copy(tmp, io.MultiReader(bytes.NewReader(head), r)); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	return file, nil
+}
+
+// Close 删除临时文件
+func (f *SpooledFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	f.file.Close()
+	return os.Remove(f.file.Name())
+}
+
+// uploadBody 上传内容，分片上传需要随机读取
+type uploadBody interface {
+	io.Reader
+	io.ReaderAt
+}
+
+// uploadMethod 按文件大小选择上传方式：超过 memoryUploadLimit 时为 resumable（分片上传），否则为 form（表单上传）
+func uploadMethod(size int64) string {
+	if size > memoryUploadLimit {
+		return "resumable"
+	}
+	return "form"
+}
+
+// put 按key模板生成存储key并上传，超过 memoryUploadLimit 时使用分片上传，mimeType 为空时由七牛云判断
+func (s *QiniuService) put(body uploadBody, size int64, filename, mimeType string) (*models.UploadResponse, error) {
 	// 生成存储key
 	key := s.generateFileKey(filename)
 
@@ -56,11 +221,28 @@ func (s *QiniuService) UploadFile(fileData []byte, filename string) (*models.Upl
 	upToken := putPolicy.UploadToken(s.mac)
 
 	// 上传文件
+	metrics.UploadsInFlight.Inc()
+	start := time.Now()
 	ret := storage.PutRet{}
-	err := s.uploader.Put(context.Background(), &ret, upToken, key, fileData, int64(len(fileData)), nil)
+	method := uploadMethod(size)
+	var err error
+	if method == "resumable" {
+		err = s.resumer.Put(context.Background(), &ret, upToken, key, body, size, &storage.RputV2Extra{MimeType: mimeType})
+	} else {
+		var putExtra *storage.PutExtra
+		if mimeType != "" {
+			putExtra = &storage.PutExtra{MimeType: mimeType}
+		}
+		err = s.uploader.Put(context.Background(), &ret, upToken, key, body, size, putExtra)
+	}
+	metrics.UploadsInFlight.Dec()
 	if err != nil {
+		metrics.UploadDuration.Observe(time.Since(start).Seconds(), method, "error")
+		metrics.ObserveQiniuError("upload", err)
 		return nil, fmt.Errorf("上传失败: %v", err)
 	}
+	metrics.UploadDuration.Observe(time.Since(start).Seconds(), method, "success")
+	metrics.UploadBytes.Add(float64(size), method)
 
 	// 构建返回结果
 	response := &models.UploadResponse{
@@ -70,51 +252,94 @@ func (s *QiniuService) UploadFile(fileData []byte, filename string) (*models.Upl
 	response.Data.Key = ret.Key
 	response.Data.Hash = ret.Hash
 	response.Data.URL = s.generateFileURL(ret.Key)
-	response.Data.FileSize = int64(len(fileData))
-	response.Data.MimeType = "image/jpeg" // 这里应该根据实际文件类型设置
-
+	response.Data.FileSize = size
 	return response, nil
 }
 
-// GetFileList 获取文件列表
-func (s *QiniuService) GetFileList(prefix string, limit int) ([]models.ImageInfo, error) {
-	entries, _, _, hasNext, err := s.bucketMgr.ListFiles(
-		s.bucket,
-		prefix,
-		"",
-		limit,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("获取文件列表失败: %v", err)
-	}
-
-	var images []models.ImageInfo
-	for _, entry := range entries {
-		// 只处理图片文件
-		if s.isImageFile(entry.Key) {
-			image := models.ImageInfo{
-				ID:       entry.Hash,
-				Key:      entry.Key,
-				URL:      s.generateFileURL(entry.Key),
-				FileSize: entry.Fsize,
-				MimeType: entry.MimeType,
-				Uploaded: time.Unix(entry.PutTime/10000000, 0).Format(time.RFC3339),
+// maxListRounds 筛选后数量不足时继续获取的最多次数
+const maxListRounds = 10
+
+// ListImages 按条件分页获取图片列表。按筛选条件过滤后数量不足时继续获取下一页，
+// 每次只请求剩余数量，保证游标不会跳过未返回的文件
+func (s *QiniuService) ListImages(query models.ImageListQuery) (*models.ImageListResponse, error) {
+	response := &models.ImageListResponse{
+		Success:  true,
+		Data:     []models.ImageInfo{},
+		Prefixes: []string{},
+		HasMore:  true,
+	}
+	marker := query.Marker
+	seen := map[string]bool{}
+
+	for round := 0; round < maxListRounds && response.HasMore && len(response.Data) < query.Limit; round++ {
+		entries, prefixes, nextMarker, hasNext, err := s.bucketMgr.ListFiles(
+			s.bucket,
+			query.Prefix,
+			query.Delimiter,
+			marker,
+			query.Limit-len(response.Data),
+		)
+		if err != nil {
+			metrics.ObserveQiniuError("list", err)
+			return nil, fmt.Errorf("获取文件列表失败: %v", err)
+		}
+
+		for _, entry := range entries {
+			// 只处理图片文件
+			if s.isImageFile(entry.Key) && matchQuery(query, entry) {
+				response.Data = append(response.Data, s.newImageInfo(entry.Key, entry.Hash, entry.Fsize, entry.MimeType, entry.PutTime))
 			}
-			images = append(images, image)
 		}
+		for _, prefix := range prefixes {
+			if !seen[prefix] {
+				seen[prefix] = true
+				response.Prefixes = append(response.Prefixes, prefix)
+			}
+		}
+
+		marker = nextMarker
+		response.HasMore = hasNext && nextMarker != ""
 	}
 
-	// 如果有更多文件，可以继续获取（这里简化处理）
-	_ = hasNext
+	if response.HasMore {
+		response.NextMarker = marker
+	}
+	response.Count = len(response.Data)
+	return response, nil
+}
 
-	return images, nil
+// matchQuery 判断文件是否符合MIME类型和上传时间条件
+func matchQuery(query models.ImageListQuery, entry storage.ListItem) bool {
+	if len(query.MimeTypes) > 0 && !utils.MatchMimeType(entry.MimeType, query.MimeTypes) {
+		return false
+	}
+	uploaded := time.Unix(0, entry.PutTime*100)
+	if !query.Since.IsZero() && uploaded.Before(query.Since) {
+		return false
+	}
+	if !query.Until.IsZero() && uploaded.After(query.Until) {
+		return false
+	}
+	return true
+}
+
+// newImageInfo 根据文件信息生成图片信息，putTime 单位为100纳秒
+func (s *QiniuService) newImageInfo(key, hash string, size int64, mimeType string, putTime int64) models.ImageInfo {
+	image := models.ImageInfo{
+		ID:       hash,
+		Key:      key,
+		URL:      s.generateFileURL(key),
+		FileSize: size,
+		MimeType: mimeType,
+		Uploaded: time.Unix(putTime/10000000, 0).Format(time.RFC3339),
+	}
+	image.ThumbnailURL = s.generateThumbnailURL(image.URL)
+	return image
 }
 
 // generateFileKey 生成文件存储key
 func (s *QiniuService) generateFileKey(filename string) string {
-	ext := filepath.Ext(filename)
-	timestamp := time.Now().Unix()
-	return fmt.Sprintf("images/%d%s", timestamp, ext)
+	return qiniu.RenderKey(s.config.KeyTemplate, s.config.KeyPrefix, filename, time.Now())
 }
 
 // generateFileURL 生成文件访问URL
@@ -123,15 +348,32 @@ func (s *QiniuService) generateFileURL(key string) string {
 		return fmt.Sprintf("https://%s/%s", s.config.QiniuDomain, key)
 	}
 
-	// 如果没有配置域名，使用七牛云默认域名
-	domain := storage.DefaultPubHosts[0]
-	return fmt.Sprintf("https://%s/%s", domain, key)
+	// 如果没有配置域名，使用七牛云默认域名格式
+	// 注意：实际使用时应该配置正确的域名
+	return fmt.Sprintf("https://example.com/%s", key)
+}
+
+// generateThumbnailURL 生成缩略图访问URL，优先使用配置的图片样式
+func (s *QiniuService) generateThumbnailURL(fileURL string) string {
+	builder := qiniu.NewImageURLBuilder(fileURL)
+	if s.config.StyleSeparator != "" {
+		builder.Separator(s.config.StyleSeparator)
+	}
+	if s.config.ThumbnailStyle != "" {
+		return builder.Style(s.config.ThumbnailStyle).String()
+	}
+
+	width, height, err := qiniu.ParseImageSize(s.config.ThumbnailSize)
+	if err != nil {
+		width, height = 300, 300
+	}
+	return builder.Thumbnail(qiniu.ViewModeCrop, width, height).String()
 }
 
 // isImageFile 检查是否为图片文件
 func (s *QiniuService) isImageFile(filename string) bool {
 	ext := filepath.Ext(filename)
-	imageExts := []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp"}
+	imageExts := []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp", ".avif"}
 
 	for _, imageExt := range imageExts {
 		if strings.EqualFold(ext, imageExt) {
@@ -139,4 +381,4 @@ func (s *QiniuService) isImageFile(filename string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
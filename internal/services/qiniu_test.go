@@ -0,0 +1,111 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"qiniu-uploader/internal/models"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+func TestMatchQuery(t *testing.T) {
+	uploaded := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	entry := storage.ListItem{Key: "a.png", MimeType: "image/png", PutTime: uploaded.UnixNano() / 100}
+
+	tests := []struct {
+		name     string
+		query    models.ImageListQuery
+		expected bool
+	}{
+		{"No filter", models.ImageListQuery{}, true},
+		{"Mime wildcard", models.ImageListQuery{MimeTypes: []string{"image/*"}}, true},
+		{"Mime mismatch", models.ImageListQuery{MimeTypes: []string{"image/jpeg", "image/webp"}}, false},
+		{"Within range", models.ImageListQuery{Since: uploaded.Add(-time.Hour), Until: uploaded.Add(time.Hour)}, true},
+		{"Before since", models.ImageListQuery{Since: uploaded.Add(time.Minute)}, false},
+		{"After until", models.ImageListQuery{Until: uploaded.Add(-time.Minute)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchQuery(tt.query, entry); got != tt.expected {
+				t.Errorf("matchQuery() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSpoolFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		inMemory bool
+	}{
+		{"Empty", 0, true},
+		{"At memory limit", memoryUploadLimit, true},
+		{"Over memory limit", memoryUploadLimit + 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := bytes.Repeat([]byte{'a'}, tt.size)
+			file, err := SpoolFile(bytes.NewReader(content))
+			if err != nil {
+				t.Fatalf("SpoolFile() error: %v", err)
+			}
+			if file.Size != int64(tt.size) {
+				t.Errorf("Size = %d, expected %d", file.Size, tt.size)
+			}
+			if (file.file == nil) != tt.inMemory {
+				t.Fatalf("in memory = %v, expected %v", file.file == nil, tt.inMemory)
+			}
+			if tt.inMemory {
+				if !bytes.Equal(file.data, content) {
+					t.Error("unexpected spooled content")
+				}
+				return
+			}
+
+			path := file.file.Name()
+			data, err := os.ReadFile(path)
+			if err != nil || !bytes.Equal(data, content) {
+				t.Errorf("temp file content differs, error: %v", err)
+			}
+			if err := file.Close(); err != nil {
+				t.Errorf("Close() error: %v", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("temp file %s should be removed after Close", path)
+			}
+		})
+	}
+}
+
+func TestSpoolFileReadError(t *testing.T) {
+	r := io.MultiReader(bytes.NewReader(make([]byte, memoryUploadLimit+1)), iotest.ErrReader(errors.New("connection reset")))
+	if _, err := SpoolFile(r); err == nil {
+		t.Error("expected read error")
+	}
+}
+
+func TestUploadMethod(t *testing.T) {
+	tests := []struct {
+		size     int64
+		expected string
+	}{
+		{0, "form"},
+		{memoryUploadLimit, "form"},
+		{memoryUploadLimit + 1, "resumable"},
+		{1 << 30, "resumable"},
+	}
+	for _, tt := range tests {
+		if got := uploadMethod(tt.size); got != tt.expected {
+			t.Errorf("uploadMethod(%d) = %q, expected %q", tt.size, got, tt.expected)
+		}
+	}
+}
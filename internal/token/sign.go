@@ -0,0 +1,99 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HMAC签名请求使用的请求头
+const (
+	HeaderTokenID       = "X-QU-Token-ID"
+	HeaderTimestamp     = "X-QU-Timestamp"
+	HeaderContentSHA256 = "X-QU-Content-SHA256"
+	HeaderSignature     = "X-QU-Signature"
+)
+
+// MaxClockSkew 签名时间与服务器时间允许的最大偏差
+const MaxClockSkew = 5 * time.Minute
+
+// 签名验证失败的原因
+var (
+	ErrBadSignature = errors.New("请求签名无效")
+	ErrStale        = errors.New("请求签名已过期，请检查客户端时钟")
+	ErrBodyMismatch = errors.New("请求体与 " + HeaderContentSHA256 + " 不一致")
+)
+
+// IsSigned 判断请求是否为HMAC签名请求
+func IsSigned(req *http.Request) bool {
+	return req.Header.Get(HeaderSignature) != ""
+}
+
+// StringToSign 签名原文：请求方法、路径（含查询参数）、时间戳和请求体SHA-256，以换行分隔
+func StringToSign(method, uri, timestamp, bodyHash string) string {
+	return strings.Join([]string{strings.ToUpper(method), uri, timestamp, bodyHash}, "\n")
+}
+
+// Sign 计算签名 hex(HMAC-SHA256(signingKey, StringToSign))
+func Sign(signingKey, method, uri, timestamp, bodyHash string) string {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(StringToSign(method, uri, timestamp, bodyHash)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// BodyHash 计算请求体的SHA-256
+func BodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// SignRequest 使用令牌为请求签名，供服务端之间调用的客户端使用
+func SignRequest(req *http.Request, raw string, body []byte, now time.Time) error {
+	id, ok := parseID(raw)
+	if !ok {
+		return ErrInvalid
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	bodyHash := BodyHash(body)
+
+	req.Header.Set(HeaderTokenID, id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderContentSHA256, bodyHash)
+	req.Header.Set(HeaderSignature, Sign(SigningKey(raw), req.Method, req.URL.RequestURI(), timestamp, bodyHash))
+	return nil
+}
+
+// VerifySignature 验证签名请求头，bodyHash 为服务端实际计算的请求体SHA-256
+func (s *Store) VerifySignature(req *http.Request, bodyHash string, now time.Time) (*Token, error) {
+	if !hmac.Equal([]byte(req.Header.Get(HeaderContentSHA256)), []byte(bodyHash)) {
+		return nil, ErrBodyMismatch
+	}
+
+	timestamp := req.Header.Get(HeaderTimestamp)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrBadSignature
+	}
+	if skew := now.Sub(time.Unix(unix, 0)); skew > MaxClockSkew || skew < -MaxClockSkew {
+		return nil, ErrStale
+	}
+
+	t, err := s.Lookup(req.Header.Get(HeaderTokenID))
+	if err != nil {
+		return nil, err
+	}
+	if !t.HMAC() {
+		return nil, ErrBadSignature
+	}
+
+	expected := Sign(t.SigningKey, req.Method, req.URL.RequestURI(), timestamp, bodyHash)
+	if !hmac.Equal([]byte(expected), []byte(req.Header.Get(HeaderSignature))) {
+		return nil, ErrBadSignature
+	}
+	return t, nil
+}
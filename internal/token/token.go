@@ -0,0 +1,345 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName 令牌文件名，位于配置目录下
+const FileName = "tokens.json"
+
+// tokenPrefix 令牌字符串前缀，格式为 qu_<ID>_<密钥>
+const tokenPrefix = "qu_"
+
+// 令牌权限
+const (
+	ScopeUpload = "upload"
+	ScopeList   = "list"
+	ScopeDelete = "delete"
+)
+
+// Scopes 全部可用的权限
+var Scopes = []string{ScopeUpload, ScopeList, ScopeDelete}
+
+// 验证失败的原因
+var (
+	ErrInvalid = errors.New("无效的API令牌")
+	ErrRevoked = errors.New("API令牌已撤销")
+	ErrExpired = errors.New("API令牌已过期")
+	// ErrHMACOnly 仅允许签名请求的令牌不能直接作为 Bearer 令牌使用
+	ErrHMACOnly = errors.New("该令牌只能用于HMAC签名请求")
+)
+
+// Token 保存在令牌文件中的记录，只保存令牌的SHA-256摘要
+type Token struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	RevokedAt time.Time `json:"revoked_at,omitempty"`
+
+	// SigningKey HMAC签名密钥（由令牌派生），服务端验证签名时需要，仅HMAC令牌保存
+	SigningKey string `json:"signing_key,omitempty"`
+}
+
+// HasScope 判断令牌是否有指定权限
+func (t *Token) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HMAC 是否为只允许签名请求的令牌
+func (t *Token) HMAC() bool {
+	return t.SigningKey != ""
+}
+
+// Active 令牌在指定时间是否可用
+func (t *Token) Active(now time.Time) error {
+	if !t.RevokedAt.IsZero() {
+		return ErrRevoked
+	}
+	if !t.ExpiresAt.IsZero() && now.After(t.ExpiresAt) {
+		return ErrExpired
+	}
+	return nil
+}
+
+// CreateOptions 创建令牌的选项
+type CreateOptions struct {
+	Name   string
+	Scopes []string
+	// TTL 有效期，0表示永久有效
+	TTL time.Duration
+	// HMAC 为true时令牌只能用于签名请求，不能作为 Bearer 令牌发送
+	HMAC bool
+}
+
+// Store 令牌存储，每次验证时重新读取文件，撤销后立即生效
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore 创建令牌存储
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Open 打开配置目录下的令牌文件
+func Open(dir string) *Store {
+	return NewStore(filepath.Join(dir, FileName))
+}
+
+// Path 令牌文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Create 创建令牌，返回的令牌字符串只在创建时可见
+func (s *Store) Create(opts CreateOptions) (string, *Token, error) {
+	scopes, err := ParseScopes(opts.Scopes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	id, err := randomString(6, hex.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+	raw := tokenPrefix + id + "_" + secret
+
+	t := &Token{
+		ID:        id,
+		Name:      opts.Name,
+		Hash:      hashToken(raw),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if opts.TTL > 0 {
+		t.ExpiresAt = t.CreatedAt.Add(opts.TTL)
+	}
+	if opts.HMAC {
+		t.SigningKey = SigningKey(raw)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens, err := s.load()
+	if err != nil {
+		return "", nil, err
+	}
+	tokens = append(tokens, *t)
+	if err := s.save(tokens); err != nil {
+		return "", nil, err
+	}
+	return raw, t, nil
+}
+
+// List 返回全部令牌，按创建时间排序
+func (s *Store) List() ([]Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(tokens, func(i, j int) bool { return tokens[i].CreatedAt.Before(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+// Revoke 按ID或名称撤销令牌
+func (s *Store) Revoke(idOrName string) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	var matched []int
+	for i, t := range tokens {
+		if t.ID == idOrName || t.Name == idOrName {
+			matched = append(matched, i)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return nil, fmt.Errorf("令牌不存在: %s", idOrName)
+	case 1:
+	default:
+		return nil, fmt.Errorf("有 %d 个令牌名为 %s，请使用ID撤销", len(matched), idOrName)
+	}
+
+	t := &tokens[matched[0]]
+	if !t.RevokedAt.IsZero() {
+		return nil, fmt.Errorf("令牌已撤销: %s", idOrName)
+	}
+	t.RevokedAt = time.Now().UTC().Truncate(time.Second)
+	if err := s.save(tokens); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Verify 验证 Bearer 令牌，返回令牌记录
+func (s *Store) Verify(raw string) (*Token, error) {
+	id, ok := parseID(raw)
+	if !ok {
+		return nil, ErrInvalid
+	}
+	t, err := s.Lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(t.Hash), []byte(hashToken(raw))) != 1 {
+		return nil, ErrInvalid
+	}
+	if t.HMAC() {
+		return nil, ErrHMACOnly
+	}
+	return t, nil
+}
+
+// Lookup 按ID查找可用的令牌
+func (s *Store) Lookup(id string) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range tokens {
+		if tokens[i].ID == id {
+			if err := tokens[i].Active(time.Now()); err != nil {
+				return nil, err
+			}
+			return &tokens[i], nil
+		}
+	}
+	return nil, ErrInvalid
+}
+
+// load 读取全部令牌，文件不存在时返回空列表
+func (s *Store) load() ([]Token, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取令牌文件失败: %v", err)
+	}
+	var tokens []Token
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("令牌文件格式错误: %v", err)
+	}
+	return tokens, nil
+}
+
+// save 以 0600 权限写入令牌文件
+func (s *Store) save(tokens []Token) error {
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入令牌文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入令牌文件失败: %v", err)
+	}
+	return nil
+}
+
+// ParseScopes 校验并去重权限列表，支持逗号分隔，为空时默认只有上传权限
+func ParseScopes(values []string) ([]string, error) {
+	var scopes []string
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, scope := range strings.Split(value, ",") {
+			scope = strings.ToLower(strings.TrimSpace(scope))
+			if scope == "" || seen[scope] {
+				continue
+			}
+			if !isScope(scope) {
+				return nil, fmt.Errorf("未知的权限: %s（可选 %s）", scope, strings.Join(Scopes, "、"))
+			}
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		scopes = []string{ScopeUpload}
+	}
+	return scopes, nil
+}
+
+// isScope 判断是否为可用的权限
+func isScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SigningKey 由令牌派生HMAC签名密钥，客户端和服务端使用相同的派生方式
+func SigningKey(raw string) string {
+	mac := hmac.New(sha256.New, []byte(raw))
+	mac.Write([]byte("qu-request-signing"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseID 从令牌字符串中解析ID
+func parseID(raw string) (string, bool) {
+	parts := strings.SplitN(raw, "_", 3)
+	if len(parts) != 3 || parts[0]+"_" != tokenPrefix || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// hashToken 计算令牌的SHA-256摘要
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString 生成 n 字节的随机串
+func randomString(n int, encode func([]byte) string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
@@ -0,0 +1,163 @@
+package token
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	return Open(t.TempDir())
+}
+
+func TestCreateAndVerify(t *testing.T) {
+	store := newTestStore(t)
+	raw, created, err := store.Create(CreateOptions{Name: "ci", Scopes: []string{"upload,list"}})
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	data, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(raw)) {
+		t.Error("token file should not contain the raw token")
+	}
+	if info, _ := os.Stat(store.Path()); info.Mode().Perm() != 0600 {
+		t.Errorf("token file mode = %v, expected 0600", info.Mode().Perm())
+	}
+
+	got, err := store.Verify(raw)
+	if err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+	if got.ID != created.ID || !got.HasScope(ScopeList) || got.HasScope(ScopeDelete) {
+		t.Errorf("Verify() = %+v, expected scopes upload,list", got)
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"Wrong secret", "qu_" + created.ID + "_wrong"},
+		{"Unknown id", "qu_000000000000_secret"},
+		{"Malformed", "not-a-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := store.Verify(tt.raw); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Verify(%q) error = %v, expected ErrInvalid", tt.raw, err)
+			}
+		})
+	}
+}
+
+func TestRevokeAndExpire(t *testing.T) {
+	store := newTestStore(t)
+	raw, _, err := store.Create(CreateOptions{Name: "laptop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Revoke("laptop"); err != nil {
+		t.Fatalf("Revoke() error: %v", err)
+	}
+	if _, err := store.Verify(raw); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Verify() error = %v, expected ErrRevoked", err)
+	}
+	if _, err := store.Revoke("laptop"); err == nil {
+		t.Error("Revoke() twice should fail")
+	}
+
+	tok := &Token{ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := tok.Active(time.Now()); !errors.Is(err, ErrExpired) {
+		t.Errorf("Active() error = %v, expected ErrExpired", err)
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []string
+		wantErr  bool
+	}{
+		{"Default", nil, []string{ScopeUpload}, false},
+		{"Comma separated", []string{"List, upload"}, []string{ScopeList, ScopeUpload}, false},
+		{"Duplicates", []string{"delete", "delete,list"}, []string{ScopeDelete, ScopeList}, false},
+		{"Unknown", []string{"admin"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScopes(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScopes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("ParseScopes() = %v, expected %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("ParseScopes() = %v, expected %v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	store := Open(filepath.Join(t.TempDir(), "nested"))
+	raw, _, err := store.Create(CreateOptions{HMAC: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Verify(raw); !errors.Is(err, ErrHMACOnly) {
+		t.Errorf("Verify() error = %v, expected ErrHMACOnly", err)
+	}
+
+	now := time.Now()
+	body := []byte("payload")
+	newRequest := func() *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost/api/upload?x=1", bytes.NewReader(body))
+		if err := SignRequest(req, raw, body, now); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	if _, err := store.VerifySignature(newRequest(), BodyHash(body), now); err != nil {
+		t.Errorf("VerifySignature() error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(req *http.Request) (bodyHash string, at time.Time)
+		want   error
+	}{
+		{"Tampered body", func(req *http.Request) (string, time.Time) {
+			return BodyHash([]byte("other")), now
+		}, ErrBodyMismatch},
+		{"Tampered path", func(req *http.Request) (string, time.Time) {
+			req.URL.Path = "/api/images"
+			return BodyHash(body), now
+		}, ErrBadSignature},
+		{"Stale", func(req *http.Request) (string, time.Time) {
+			return BodyHash(body), now.Add(2 * MaxClockSkew)
+		}, ErrStale},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest()
+			bodyHash, at := tt.modify(req)
+			if _, err := store.VerifySignature(req, bodyHash, at); !errors.Is(err, tt.want) {
+				t.Errorf("VerifySignature() error = %v, expected %v", err, tt.want)
+			}
+		})
+	}
+}
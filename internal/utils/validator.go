@@ -2,15 +2,20 @@ package utils
 
 import (
 	"mime"
-	"net/http"
+	"mime/multipart"
 	"path/filepath"
 	"strings"
 )
 
-// ValidateFile 验证上传文件
-func ValidateFile(fileHeader *http.Request, maxSize int64, allowedTypes []string) error {
+// ValidateFile 验证上传文件的大小和MIME类型
+func ValidateFile(fileHeader *multipart.FileHeader, maxSize int64, allowedTypes []string) error {
+	return ValidateUpload(fileHeader.Size, fileHeader.Header.Get("Content-Type"), maxSize, allowedTypes)
+}
+
+// ValidateUpload 验证文件大小和MIME类型，用于已知大小和类型的上传（如浏览器直传）
+func ValidateUpload(size int64, contentType string, maxSize int64, allowedTypes []string) error {
 	// 检查文件大小
-	if fileHeader.ContentLength > maxSize {
+	if size > maxSize {
 		return &ValidationError{
 			Code:    "FILE_TOO_LARGE",
 			Message: "文件大小超过限制",
@@ -18,7 +23,6 @@ func ValidateFile(fileHeader *http.Request, maxSize int64, allowedTypes []string
 	}
 
 	// 检查文件类型
-	contentType := fileHeader.Header.Get("Content-Type")
 	if !isAllowedType(contentType, allowedTypes) {
 		return &ValidationError{
 			Code:    "INVALID_FILE_TYPE",
@@ -47,6 +51,11 @@ func ValidateFilename(filename string) error {
 	}
 }
 
+// MatchMimeType 检查MIME类型是否匹配列表中的任一类型，支持 image/* 通配
+func MatchMimeType(contentType string, patterns []string) bool {
+	return isAllowedType(contentType, patterns)
+}
+
 // isAllowedType 检查文件类型是否在允许列表中
 func isAllowedType(contentType string, allowedTypes []string) bool {
 	for _, allowedType := range allowedTypes {
@@ -0,0 +1,115 @@
+package imaging
+
+import (
+	"bytes"
+	"io"
+	"sort"
+)
+
+// Body 由内存数据和源文件区间拼接的只读内容，支持顺序读取和并发的随机读取
+type Body struct {
+	parts  []*io.SectionReader
+	starts []int64
+	size   int64
+	pos    int64
+}
+
+// Size 内容总大小
+func (b *Body) Size() int64 {
+	return b.size
+}
+
+// ReadAt 实现 io.ReaderAt，可并发调用
+func (b *Body) ReadAt(p []byte, off int64) (int, error) {
+	if off >= b.size {
+		return 0, io.EOF
+	}
+	i := sort.Search(len(b.starts), func(i int) bool { return b.starts[i] > off }) - 1
+
+	n := 0
+	for n < len(p) && i < len(b.parts) {
+		m, err := b.parts[i].ReadAt(p[n:], off+int64(n)-b.starts[i])
+		n += m
+		switch {
+		case err == io.EOF:
+			i++
+		case err != nil:
+			return n, err
+		}
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Read 实现 io.Reader
+func (b *Body) Read(p []byte) (int, error) {
+	n, err := b.ReadAt(p, b.pos)
+	b.pos += int64(n)
+	return n, err
+}
+
+// bodyBuilder 按顺序拼接内存数据和源文件区间
+type bodyBuilder struct {
+	src   io.ReaderAt
+	parts []bodyPart
+}
+
+// bodyPart 内存数据，或 data 为nil时为源文件中 [off, off+n) 的区间
+type bodyPart struct {
+	data   []byte
+	off, n int64
+}
+
+// copy 追加源文件区间，与上一个区间相邻时合并
+func (b *bodyBuilder) copy(off, n int64) {
+	if last := len(b.parts) - 1; last >= 0 && b.parts[last].data == nil && b.parts[last].off+b.parts[last].n == off {
+		b.parts[last].n += n
+		return
+	}
+	b.parts = append(b.parts, bodyPart{off: off, n: n})
+}
+
+// write 追加内存数据，body 生成前仍可修改 data
+func (b *bodyBuilder) write(data []byte) {
+	b.parts = append(b.parts, bodyPart{data: data, n: int64(len(data))})
+}
+
+// read 从源文件读取 n 字节
+func (b *bodyBuilder) read(off, n int64) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := b.src.ReadAt(buf, off); err != nil && !(err == io.EOF && n == 0) {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// size 已追加内容的总大小
+func (b *bodyBuilder) size() int64 {
+	var total int64
+	for _, part := range b.parts {
+		total += part.n
+	}
+	return total
+}
+
+// body 生成拼接后的内容
+func (b *bodyBuilder) body() *Body {
+	body := &Body{}
+	for _, part := range b.parts {
+		if part.n == 0 {
+			continue
+		}
+		var section *io.SectionReader
+		if part.data != nil {
+			section = io.NewSectionReader(bytes.NewReader(part.data), 0, part.n)
+		} else {
+			section = io.NewSectionReader(b.src, part.off, part.n)
+		}
+		body.parts = append(body.parts, section)
+		body.starts = append(body.starts, body.size)
+		body.size += part.n
+	}
+	return body
+}
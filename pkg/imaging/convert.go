@@ -0,0 +1,154 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 支持转换的目标格式
+const (
+	FormatWebP = "webp"
+	FormatAVIF = "avif"
+)
+
+// ConvertSkipLarger 转换后体积更大时的跳过原因
+const ConvertSkipLarger = "转换后体积更大"
+
+// EncoderNotFoundError 未安装格式转换所需的外部编码器
+type EncoderNotFoundError struct {
+	Name string
+}
+
+func (e *EncoderNotFoundError) Error() string {
+	return fmt.Sprintf("未找到 %s，请先安装后再使用格式转换", e.Name)
+}
+
+// IsEncoderNotFound 判断错误是否由缺少编码器引起
+func IsEncoderNotFound(err error) bool {
+	var notFound *EncoderNotFoundError
+	return errors.As(err, &notFound)
+}
+
+// 转换的默认质量
+const (
+	DefaultWebPQuality = 80
+	DefaultAVIFQuality = 60
+)
+
+// encoderFunc 将PNG/JPEG数据编码为目标格式
+type encoderFunc func(data []byte, quality int) ([]byte, error)
+
+// encoders 目标格式对应的编码器，依赖本地安装的 cwebp/avifenc
+var encoders = map[string]encoderFunc{
+	FormatWebP: func(data []byte, quality int) ([]byte, error) {
+		return runEncoder("cwebp", func(in, out string) []string {
+			return []string{"-quiet", "-q", strconv.Itoa(quality), "-metadata", "none", in, "-o", out}
+		}, data)
+	},
+	FormatAVIF: func(data []byte, quality int) ([]byte, error) {
+		return runEncoder("avifenc", func(in, out string) []string {
+			return []string{"-q", strconv.Itoa(quality), "--ignore-exif", "--ignore-xmp", in, out}
+		}, data)
+	},
+}
+
+// ParseConvertFormat 校验转换目标格式，空字符串表示不转换
+func ParseConvertFormat(format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case "", "none":
+		return "", nil
+	case FormatWebP, FormatAVIF:
+		return format, nil
+	}
+	return "", fmt.Errorf("不支持的转换格式: %s（支持 webp、avif）", format)
+}
+
+// FormatExtension 返回格式对应的文件扩展名
+func FormatExtension(format string) string {
+	switch format {
+	case "jpeg":
+		return ".jpg"
+	case "":
+		return ""
+	}
+	return "." + format
+}
+
+// FormatMIMEType 返回格式对应的MIME类型
+func FormatMIMEType(format string) string {
+	if format == "" {
+		return "application/octet-stream"
+	}
+	return "image/" + format
+}
+
+// convert 将JPEG/PNG转换为目标格式，orientation 不为1时先旋正
+func convert(data []byte, format string, quality, orientation int) ([]byte, error) {
+	encode, ok := encoders[format]
+	if !ok {
+		return nil, fmt.Errorf("不支持的转换格式: %s", format)
+	}
+
+	// 编码器不识别EXIF方向，先把方向应用到像素上
+	if orientation > 1 {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("解码图片失败: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, ApplyOrientation(img, orientation)); err != nil {
+			return nil, fmt.Errorf("编码图片失败: %v", err)
+		}
+		data = buf.Bytes()
+	}
+
+	if quality <= 0 {
+		quality = DefaultWebPQuality
+		if format == FormatAVIF {
+			quality = DefaultAVIFQuality
+		}
+	}
+	return encode(data, quality)
+}
+
+// runEncoder 通过临时文件调用外部编码器
+func runEncoder(name string, args func(in, out string) []string, data []byte) ([]byte, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, &EncoderNotFoundError{Name: name}
+	}
+
+	dir, err := os.MkdirTemp("", "qu-convert-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	// 部分编码器根据扩展名识别输入格式
+	in := filepath.Join(dir, "input.jpg")
+	if bytes.HasPrefix(data, pngSignature) {
+		in = filepath.Join(dir, "input.png")
+	}
+	out := filepath.Join(dir, "output")
+	if err := os.WriteFile(in, data, 0600); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(path, args(in, out)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s 转换失败: %v %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return os.ReadFile(out)
+}
@@ -0,0 +1,290 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// DefaultJPEGQuality 缩放后重新编码JPEG的默认质量
+const DefaultJPEGQuality = 85
+
+// Options 上传前的图片预处理选项
+type Options struct {
+	// MaxWidth/MaxHeight 最大宽高，超出时等比缩小，0表示不限制
+	MaxWidth  int
+	MaxHeight int
+
+	// Quality JPEG重新压缩质量 (1-100)，0表示仅在缩放时使用默认质量
+	Quality int
+
+	// OptimizePNG 使用最高压缩级别重新编码PNG
+	OptimizePNG bool
+
+	// StripMetadata 移除EXIF、XMP、IPTC等元数据（保留方向）
+	StripMetadata bool
+
+	// Convert 将JPEG/PNG转换为指定格式 (webp、avif)，为空表示不转换
+	Convert string
+	// RequireEncoder 未安装编码器时返回错误，为false时保留原格式并在结果中说明原因
+	RequireEncoder bool
+}
+
+// Enabled 是否启用了任意预处理
+func (o Options) Enabled() bool {
+	return o.Reencodes() || o.StripMetadata
+}
+
+// Reencodes 是否可能解码并重新编码图片（缩放、压缩或转换格式），仅清除元数据时不需要读入完整图片
+func (o Options) Reencodes() bool {
+	return o.MaxWidth > 0 || o.MaxHeight > 0 || o.Quality > 0 || o.OptimizePNG || o.Convert != ""
+}
+
+// Result 预处理结果
+type Result struct {
+	Data []byte
+
+	// Format 最终格式，OriginalFormat 原始格式；名称与 image.DecodeConfig 一致，无法识别时为空
+	Format         string
+	OriginalFormat string
+	// ConvertSkipped 为true表示未进行格式转换，已保留原格式，
+	// ConvertSkipReason 为原因（ConvertSkipLarger 或缺少编码器）
+	ConvertSkipped    bool
+	ConvertSkipReason string
+
+	OriginalSize   int64
+	FinalSize      int64
+	OriginalWidth  int
+	OriginalHeight int
+	Width          int
+	Height         int
+
+	// MetadataRemoved 被移除的元数据类型，GPSRemoved 表示移除了GPS位置信息
+	MetadataRemoved []string
+	GPSRemoved      bool
+
+	// Processed 为false表示未做任何修改，Data即原始数据
+	Processed bool
+}
+
+// Process 按选项缩放、重新压缩、转换格式并移除元数据；
+// 缩放、压缩和转换仅处理JPEG和PNG，元数据清除额外支持WebP，其余格式原样返回
+func Process(data []byte, opts Options) (*Result, error) {
+	result := &Result{
+		Data:         data,
+		OriginalSize: int64(len(data)),
+		FinalSize:    int64(len(data)),
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// 无法解码的格式（如AVIF）原样上传
+		return result, nil
+	}
+	result.Format = format
+	result.OriginalFormat = format
+	result.OriginalWidth, result.OriginalHeight = cfg.Width, cfg.Height
+	result.Width, result.Height = cfg.Width, cfg.Height
+
+	if !opts.Enabled() {
+		return result, nil
+	}
+
+	// 先无损清除元数据，重新编码时不会写入任何元数据
+	original := data
+	if opts.StripMetadata {
+		stripped, err := StripMetadata(data)
+		if err != nil {
+			return nil, fmt.Errorf("清除元数据失败: %v", err)
+		}
+		if len(stripped.Removed) > 0 {
+			result.setData(stripped.Data)
+			result.MetadataRemoved = stripped.Removed
+			result.GPSRemoved = stripped.HadGPS
+		}
+	}
+
+	if format != "jpeg" && format != "png" {
+		return result, nil
+	}
+
+	// PNG的方向保存在eXIf块中，重新编码前同样需要应用到像素上
+	orientation := ReadOrientation(original)
+
+	// 按显示方向计算目标尺寸
+	displayWidth, displayHeight := cfg.Width, cfg.Height
+	if orientation >= 5 {
+		displayWidth, displayHeight = displayHeight, displayWidth
+	}
+	result.Width, result.Height = displayWidth, displayHeight
+	width, height := fitSize(displayWidth, displayHeight, opts.MaxWidth, opts.MaxHeight)
+	resized := width != displayWidth || height != displayHeight
+
+	if resized || (format == "jpeg" && opts.Quality > 0) || (format == "png" && opts.OptimizePNG) {
+		encoded, err := recompress(result.Data, format, orientation, width, height, resized, opts)
+		if err != nil {
+			return nil, err
+		}
+		// 未缩放且重新压缩后反而更大时保留原图
+		if resized || len(encoded) < len(result.Data) {
+			result.setData(encoded)
+			result.Width, result.Height = width, height
+			// 方向已应用到像素上
+			orientation = 1
+			if !opts.StripMetadata {
+				result.noteLostMetadata(original)
+			}
+		}
+	}
+
+	if opts.Convert != "" && opts.Convert != format {
+		converted, err := convert(result.Data, opts.Convert, opts.Quality, orientation)
+		if err != nil && !opts.RequireEncoder && IsEncoderNotFound(err) {
+			result.ConvertSkipped = true
+			result.ConvertSkipReason = err.Error()
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// 转换后更大时保留原格式
+		if len(converted) >= len(result.Data) {
+			result.ConvertSkipped = true
+			result.ConvertSkipReason = ConvertSkipLarger
+			return result, nil
+		}
+		result.setData(converted)
+		result.Format = opts.Convert
+		if !opts.StripMetadata {
+			result.noteLostMetadata(original)
+		}
+	}
+
+	return result, nil
+}
+
+// setData 替换处理后的数据
+func (r *Result) setData(data []byte) {
+	r.Data = data
+	r.FinalSize = int64(len(data))
+	r.Processed = true
+}
+
+// noteLostMetadata 重新编码会丢失所有元数据，如实记录
+func (r *Result) noteLostMetadata(original []byte) {
+	if stripped, err := StripMetadata(original); err == nil {
+		r.MetadataRemoved = stripped.Removed
+		r.GPSRemoved = stripped.HadGPS
+	}
+}
+
+// recompress 解码后按方向旋正、缩放，并以原格式重新编码
+func recompress(data []byte, format string, orientation, width, height int, resized bool, opts Options) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解码图片失败: %v", err)
+	}
+
+	// 重新编码会丢失EXIF，先把方向应用到像素上
+	img = ApplyOrientation(img, orientation)
+	if resized {
+		img = resize(img, width, height)
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		quality := opts.Quality
+		if quality <= 0 {
+			quality = DefaultJPEGQuality
+		}
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	case "png":
+		encoder := png.Encoder{CompressionLevel: png.DefaultCompression}
+		if opts.OptimizePNG {
+			encoder.CompressionLevel = png.BestCompression
+		}
+		err = encoder.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("编码图片失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// fitSize 计算等比缩放到最大宽高以内的尺寸，不放大
+func fitSize(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		if s := float64(maxHeight) / float64(height); s < scale {
+			scale = s
+		}
+	}
+	if scale == 1.0 {
+		return width, height
+	}
+
+	w := int(float64(width)*scale + 0.5)
+	h := int(float64(height)*scale + 0.5)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// resize 使用 Catmull-Rom 插值缩放图片
+func resize(img image.Image, width, height int) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// Info 图片基本信息
+type Info struct {
+	Format string
+	Width  int
+	Height int
+}
+
+// infoHeaderSize 读取图片信息时最多读取的字节数，需覆盖EXIF段
+const infoHeaderSize = 256 * 1024
+
+// ReadInfo 读取图片文件的格式和显示尺寸（已考虑EXIF方向）
+func ReadInfo(path string) (*Info, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header, err := io.ReadAll(io.LimitReader(file, infoHeaderSize))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(header))
+	if err != nil {
+		return nil, fmt.Errorf("无法识别的图片格式: %v", err)
+	}
+
+	info := &Info{Format: format, Width: cfg.Width, Height: cfg.Height}
+	if ReadOrientation(header) >= 5 {
+		info.Width, info.Height = info.Height, info.Width
+	}
+	return info, nil
+}
@@ -0,0 +1,420 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// newTestImage 创建左半红、右半蓝的测试图片
+func newTestImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= width/2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// encodeJPEG 编码JPEG，orientation大于0时插入EXIF方向
+func encodeJPEG(t *testing.T, img image.Image, orientation int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if orientation == 0 {
+		return data
+	}
+
+	tiff := buildOrientationExif(orientation)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestReadOrientation(t *testing.T) {
+	img := newTestImage(8, 4)
+	for _, orientation := range []int{1, 3, 6, 8} {
+		data := encodeJPEG(t, img, orientation)
+		if got := ReadOrientation(data); got != orientation {
+			t.Errorf("ReadOrientation() = %d, expected %d", got, orientation)
+		}
+	}
+
+	if got := ReadOrientation(encodeJPEG(t, img, 0)); got != 1 {
+		t.Errorf("ReadOrientation() without EXIF = %d, expected 1", got)
+	}
+	if got := ReadOrientation([]byte("not a jpeg")); got != 1 {
+		t.Errorf("ReadOrientation() on invalid data = %d, expected 1", got)
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	img := newTestImage(4, 2)
+
+	rotated := ApplyOrientation(img, 6)
+	if b := rotated.Bounds(); b.Dx() != 2 || b.Dy() != 4 {
+		t.Fatalf("rotated bounds = %v, expected 2x4", b)
+	}
+	// 顺时针旋转90度后，原左半部分（红）应位于上半部分
+	if r, _, _, _ := rotated.At(0, 0).RGBA(); r == 0 {
+		t.Error("expected red pixel at top after 90° rotation")
+	}
+	if _, _, b, _ := rotated.At(0, 3).RGBA(); b == 0 {
+		t.Error("expected blue pixel at bottom after 90° rotation")
+	}
+
+	if ApplyOrientation(img, 1) != image.Image(img) {
+		t.Error("orientation 1 should return the original image")
+	}
+}
+
+func TestProcessResizeJPEG(t *testing.T) {
+	data := encodeJPEG(t, newTestImage(400, 200), 6)
+
+	result, err := Process(data, Options{MaxWidth: 50})
+	if err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+	if !result.Processed {
+		t.Fatal("expected image to be processed")
+	}
+	// EXIF方向为6，显示尺寸为200x400，等比缩小到宽50
+	if result.Width != 50 || result.Height != 100 {
+		t.Errorf("result size = %dx%d, expected 50x100", result.Width, result.Height)
+	}
+	if result.FinalSize != int64(len(result.Data)) || result.OriginalSize != int64(len(data)) {
+		t.Error("result sizes do not match data")
+	}
+	if ReadOrientation(result.Data) != 1 {
+		t.Error("processed image should not carry an orientation tag")
+	}
+}
+
+func TestProcessResizePNGWithExif(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newTestImage(400, 200)); err != nil {
+		t.Fatal(err)
+	}
+	plain := buf.Bytes()
+
+	ihdrEnd := len(pngSignature) + 12 + 13
+	var chunk bytes.Buffer
+	writePNGChunk(&chunk, "eXIf", buildOrientationExif(6))
+	data := append(append(append([]byte{}, plain[:ihdrEnd]...), chunk.Bytes()...), plain[ihdrEnd:]...)
+
+	if got := ReadOrientation(data); got != 6 {
+		t.Fatalf("ReadOrientation() on PNG = %d, expected 6", got)
+	}
+
+	for _, opts := range []Options{{MaxWidth: 50}, {MaxWidth: 50, StripMetadata: true}} {
+		result, err := Process(data, opts)
+		if err != nil {
+			t.Fatalf("Process() error: %v", err)
+		}
+		// eXIf方向为6，显示尺寸为200x400，等比缩小到宽50
+		if result.Width != 50 || result.Height != 100 {
+			t.Errorf("result size = %dx%d, expected 50x100", result.Width, result.Height)
+		}
+		cfg, err := png.DecodeConfig(bytes.NewReader(result.Data))
+		if err != nil {
+			t.Fatalf("processed PNG is not decodable: %v", err)
+		}
+		if cfg.Width != 50 || cfg.Height != 100 {
+			t.Errorf("encoded size = %dx%d, expected rotated 50x100", cfg.Width, cfg.Height)
+		}
+		if ReadOrientation(result.Data) != 1 {
+			t.Error("processed image should not carry an orientation tag")
+		}
+	}
+}
+
+func TestProcessKeepsOriginal(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newTestImage(20, 20)); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"Disabled", Options{}},
+		{"Smaller than limit", Options{MaxWidth: 100, MaxHeight: 100}},
+		{"JPEG quality on PNG", Options{Quality: 50}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Process(data, tt.opts)
+			if err != nil {
+				t.Fatalf("Process() error: %v", err)
+			}
+			if result.Processed || !bytes.Equal(result.Data, data) {
+				t.Error("expected original data to be kept")
+			}
+		})
+	}
+}
+
+// buildGPSExif 构造包含方向和GPS子IFD的EXIF数据
+func buildGPSExif(orientation int) []byte {
+	tiff := make([]byte, 8+2+2*12+4+2+12+4)
+	copy(tiff, "II")
+	binary.LittleEndian.PutUint16(tiff[2:], 0x2A)
+	binary.LittleEndian.PutUint32(tiff[4:], 8)
+	binary.LittleEndian.PutUint16(tiff[8:], 2)
+	binary.LittleEndian.PutUint16(tiff[10:], exifOrientationTag)
+	binary.LittleEndian.PutUint16(tiff[12:], 3)
+	binary.LittleEndian.PutUint32(tiff[14:], 1)
+	binary.LittleEndian.PutUint16(tiff[18:], uint16(orientation))
+	gpsOffset := 8 + 2 + 2*12 + 4
+	binary.LittleEndian.PutUint16(tiff[22:], exifGPSInfoTag)
+	binary.LittleEndian.PutUint16(tiff[24:], 4) // LONG
+	binary.LittleEndian.PutUint32(tiff[26:], 1)
+	binary.LittleEndian.PutUint32(tiff[30:], uint32(gpsOffset))
+	binary.LittleEndian.PutUint16(tiff[gpsOffset:], 1)
+	binary.LittleEndian.PutUint16(tiff[gpsOffset+2:], 0x0001) // GPSLatitudeRef
+	return tiff
+}
+
+// jpegSegment 构造JPEG段
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func TestStripMetadataJPEG(t *testing.T) {
+	plain := encodeJPEG(t, newTestImage(8, 8), 0)
+
+	data := append([]byte{}, plain[:2]...)
+	data = append(data, jpegSegment(0xE1, append([]byte("Exif\x00\x00"), buildGPSExif(6)...))...)
+	data = append(data, jpegSegment(0xE1, append(append([]byte{}, xmpJPEGHeader...), "<x:xmpmeta/>"...))...)
+	data = append(data, jpegSegment(0xED, []byte("Photoshop 3.0\x00"))...)
+	data = append(data, plain[2:]...)
+
+	result, err := StripMetadata(data)
+	if err != nil {
+		t.Fatalf("StripMetadata() error: %v", err)
+	}
+	if !result.HadGPS {
+		t.Error("expected GPS to be detected")
+	}
+	if len(result.Removed) != 3 {
+		t.Errorf("Removed = %v, expected EXIF, XMP and IPTC", result.Removed)
+	}
+	if ReadOrientation(result.Data) != 6 {
+		t.Error("orientation should be preserved")
+	}
+	if _, hasGPS := parseExif(findExifSegment(result.Data)[6:]); hasGPS {
+		t.Error("GPS should be removed")
+	}
+	if bytes.Contains(result.Data, []byte("xmpmeta")) || bytes.Contains(result.Data, []byte("Photoshop")) {
+		t.Error("XMP and IPTC should be removed")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(result.Data)); err != nil {
+		t.Errorf("stripped JPEG is not decodable: %v", err)
+	}
+}
+
+func TestStripMetadataPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newTestImage(8, 8)); err != nil {
+		t.Fatal(err)
+	}
+	plain := buf.Bytes()
+
+	// 在IHDR之后插入文本块和EXIF块
+	ihdrEnd := len(pngSignature) + 12 + 13
+	var chunks bytes.Buffer
+	writePNGChunk(&chunks, "tEXt", []byte("Comment\x00hello"))
+	writePNGChunk(&chunks, "iTXt", []byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00<x:xmpmeta/>"))
+	writePNGChunk(&chunks, "eXIf", buildGPSExif(1))
+	data := append(append(append([]byte{}, plain[:ihdrEnd]...), chunks.Bytes()...), plain[ihdrEnd:]...)
+
+	result, err := StripMetadata(data)
+	if err != nil {
+		t.Fatalf("StripMetadata() error: %v", err)
+	}
+	if !result.HadGPS {
+		t.Error("expected GPS to be detected")
+	}
+	if !bytes.Equal(result.Data, plain) {
+		t.Error("expected all metadata chunks to be removed")
+	}
+}
+
+func TestStripMetadataWebP(t *testing.T) {
+	var body bytes.Buffer
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagEXIF | webpFlagXMP
+	writeWebPChunk(&body, "VP8X", vp8x)
+	writeWebPChunk(&body, "VP8L", []byte{0x2f, 0, 0, 0, 0})
+	writeWebPChunk(&body, "EXIF", buildGPSExif(1))
+	writeWebPChunk(&body, "XMP ", []byte("<x:xmpmeta/>"))
+
+	data := append([]byte("RIFF\x00\x00\x00\x00WEBP"), body.Bytes()...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+
+	result, err := StripMetadata(data)
+	if err != nil {
+		t.Fatalf("StripMetadata() error: %v", err)
+	}
+	if !result.HadGPS || len(result.Removed) != 2 {
+		t.Errorf("HadGPS = %v, Removed = %v", result.HadGPS, result.Removed)
+	}
+	if bytes.Contains(result.Data, []byte("EXIF")) || bytes.Contains(result.Data, []byte("XMP ")) {
+		t.Error("EXIF and XMP chunks should be removed")
+	}
+	if flags := result.Data[20]; flags&(webpFlagEXIF|webpFlagXMP) != 0 {
+		t.Errorf("VP8X flags = %#x, expected metadata flags cleared", flags)
+	}
+	if size := binary.LittleEndian.Uint32(result.Data[4:]); int(size) != len(result.Data)-8 {
+		t.Errorf("RIFF size = %d, expected %d", size, len(result.Data)-8)
+	}
+}
+
+func TestProcessConvert(t *testing.T) {
+	data := encodeJPEG(t, newTestImage(40, 20), 0)
+
+	original := encoders[FormatWebP]
+	defer func() { encoders[FormatWebP] = original }()
+
+	encoders[FormatWebP] = func(data []byte, quality int) ([]byte, error) {
+		if quality != DefaultWebPQuality {
+			t.Errorf("quality = %d, expected default %d", quality, DefaultWebPQuality)
+		}
+		return []byte("RIFF\x00\x00\x00\x00WEBP"), nil
+	}
+	result, err := Process(data, Options{Convert: FormatWebP})
+	if err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+	if result.Format != FormatWebP || result.OriginalFormat != "jpeg" || !result.Processed {
+		t.Errorf("Format = %q, OriginalFormat = %q, Processed = %v", result.Format, result.OriginalFormat, result.Processed)
+	}
+
+	// 转换后更大时保留原格式
+	encoders[FormatWebP] = func(data []byte, quality int) ([]byte, error) {
+		return make([]byte, len(data)+1), nil
+	}
+	result, err = Process(data, Options{Convert: FormatWebP})
+	if err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+	if result.Format != "jpeg" || !result.ConvertSkipped || !bytes.Equal(result.Data, data) {
+		t.Errorf("expected original JPEG to be kept, got format %q", result.Format)
+	}
+	if result.ConvertSkipReason != ConvertSkipLarger {
+		t.Errorf("ConvertSkipReason = %q, expected %q", result.ConvertSkipReason, ConvertSkipLarger)
+	}
+
+	// 未安装编码器时保留原格式，显式要求转换时返回错误
+	encoders[FormatWebP] = func(data []byte, quality int) ([]byte, error) {
+		return nil, &EncoderNotFoundError{Name: "cwebp"}
+	}
+	result, err = Process(data, Options{Convert: FormatWebP})
+	if err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+	if result.Format != "jpeg" || !result.ConvertSkipped || !strings.Contains(result.ConvertSkipReason, "cwebp") {
+		t.Errorf("Format = %q, ConvertSkipReason = %q, expected original JPEG with reason", result.Format, result.ConvertSkipReason)
+	}
+	if _, err := Process(data, Options{Convert: FormatWebP, RequireEncoder: true}); !IsEncoderNotFound(err) {
+		t.Errorf("Process() error = %v, expected missing encoder error", err)
+	}
+}
+
+func TestParseConvertFormat(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    string
+		shouldError bool
+	}{
+		{"", "", false},
+		{"none", "", false},
+		{"WebP", FormatWebP, false},
+		{"avif", FormatAVIF, false},
+		{"heic", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseConvertFormat(tt.input)
+		if tt.shouldError != (err != nil) || got != tt.expected {
+			t.Errorf("ParseConvertFormat(%q) = %q, %v", tt.input, got, err)
+		}
+	}
+}
+
+// countingReaderAt 统计从源数据读取的字节数
+type countingReaderAt struct {
+	data []byte
+	read int
+}
+
+func (r *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := bytes.NewReader(r.data).ReadAt(p, off)
+	r.read += n
+	return n, err
+}
+
+func TestStripMetadataAt(t *testing.T) {
+	plain := encodeJPEG(t, newTestImage(8, 8), 0)
+	data := append([]byte{}, plain[:2]...)
+	data = append(data, jpegSegment(0xE1, append([]byte("Exif\x00\x00"), buildGPSExif(6)...))...)
+	data = append(data, jpegSegment(0xED, []byte("Photoshop 3.0\x00"))...)
+	data = append(data, plain[2:]...)
+	// 模拟较大的图像数据
+	data = append(data[:len(data)-2], bytes.Repeat([]byte{0x55}, 1<<20)...)
+	data = append(data, 0xFF, 0xD9)
+
+	expected, err := StripMetadata(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := &countingReaderAt{data: data}
+	result, err := StripMetadataAt(src, int64(len(data)))
+	if err != nil {
+		t.Fatalf("StripMetadataAt() error: %v", err)
+	}
+	if src.read > 64<<10 {
+		t.Errorf("read %d bytes before upload, expected only the metadata segments", src.read)
+	}
+	if result.Data != nil || result.Body.Size() != int64(len(expected.Data)) {
+		t.Fatalf("Body.Size() = %d, expected %d", result.Body.Size(), len(expected.Data))
+	}
+
+	// 随机读取与顺序读取的内容一致
+	for _, off := range []int64{0, 1, 100, result.Body.Size() - 10} {
+		buf := make([]byte, 10)
+		if _, err := result.Body.ReadAt(buf, off); err != nil {
+			t.Fatalf("ReadAt(%d) error: %v", off, err)
+		}
+		if !bytes.Equal(buf, expected.Data[off:off+10]) {
+			t.Errorf("ReadAt(%d) = %x, expected %x", off, buf, expected.Data[off:off+10])
+		}
+	}
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(result.Body); err != nil || !bytes.Equal(out.Bytes(), expected.Data) {
+		t.Errorf("Read() returned different content, error: %v", err)
+	}
+}
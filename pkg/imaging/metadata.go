@@ -0,0 +1,339 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+)
+
+// 元数据类型
+const (
+	MetadataEXIF = "EXIF"
+	MetadataXMP  = "XMP"
+	MetadataIPTC = "IPTC"
+	MetadataText = "Text"
+)
+
+// StripResult 元数据清除结果
+type StripResult struct {
+	Data []byte
+
+	// Removed 被移除的元数据类型，按首次出现顺序排列
+	Removed []string
+	// HadGPS 原图是否包含GPS位置信息
+	HadGPS bool
+	// Orientation 原图的EXIF方向，不为1时会以最小EXIF保留
+	Orientation int
+
+	// Body 处理后的内容，支持顺序读取和随机读取
+	Body *Body
+}
+
+var (
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+	xmpJPEGHeader = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	xmpExtHeader  = []byte("http://ns.adobe.com/xmp/extension/\x00")
+)
+
+// StripMetadata 无损移除JPEG、PNG、WebP中的EXIF、XMP、IPTC元数据，
+// 保留方向信息和颜色配置；其他格式原样返回
+func StripMetadata(data []byte) (*StripResult, error) {
+	result, err := StripMetadataAt(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	if result.Data, err = io.ReadAll(result.Body); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StripMetadataAt 与 StripMetadata 相同，但只把元数据所在的结构读入内存，
+// 图像数据在读取 Body 时按需从 src 读取，适合处理临时文件中的大图片。返回结果的 Data 为nil
+func StripMetadataAt(src io.ReaderAt, size int64) (*StripResult, error) {
+	head := make([]byte, 12)
+	n, err := src.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	b := &bodyBuilder{src: src}
+	var result *StripResult
+	switch {
+	case len(head) >= 2 && head[0] == 0xFF && head[1] == 0xD8:
+		result, err = stripJPEG(b, size)
+	case bytes.HasPrefix(head, pngSignature):
+		result, err = stripPNG(b, size)
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		result, err = stripWebP(b, size)
+	default:
+		b.copy(0, size)
+		result = &StripResult{Orientation: 1}
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Body = b.body()
+	return result, nil
+}
+
+// add 记录被移除的元数据类型
+func (r *StripResult) add(kind string) {
+	for _, k := range r.Removed {
+		if k == kind {
+			return
+		}
+	}
+	r.Removed = append(r.Removed, kind)
+}
+
+// addExif 记录EXIF信息，返回需要保留的最小EXIF（无需保留时为nil）
+func (r *StripResult) addExif(tiff []byte) []byte {
+	r.add(MetadataEXIF)
+	orientation, hasGPS := parseExif(tiff)
+	if hasGPS {
+		r.HadGPS = true
+	}
+	if orientation != 1 {
+		r.Orientation = orientation
+		return buildOrientationExif(orientation)
+	}
+	return nil
+}
+
+// stripJPEG 移除JPEG的APP1(EXIF/XMP)和APP13(IPTC)段
+func stripJPEG(b *bodyBuilder, size int64) (*StripResult, error) {
+	invalid := fmt.Errorf("无效的JPEG数据")
+	result := &StripResult{Orientation: 1}
+	b.copy(0, 2)
+
+	pos := int64(2)
+	for {
+		if pos+4 > size {
+			return nil, invalid
+		}
+		header, err := b.read(pos, 4)
+		if err != nil || header[0] != 0xFF {
+			return nil, invalid
+		}
+		marker := header[1]
+		// 填充字节
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		// SOS之后为图像数据，原样保留
+		if marker == 0xDA || marker == 0xD9 {
+			b.copy(pos, size-pos)
+			break
+		}
+
+		length := int64(binary.BigEndian.Uint16(header[2:]))
+		if length < 2 || pos+2+length > size {
+			return nil, invalid
+		}
+
+		switch marker {
+		case 0xE1:
+			// APP1段不超过64KB，读入内存判断类型
+			payload, err := b.read(pos+4, length-2)
+			if err != nil {
+				return nil, invalid
+			}
+			switch {
+			case bytes.HasPrefix(payload, []byte("Exif\x00\x00")):
+				if tiff := result.addExif(payload[6:]); tiff != nil {
+					exif := append([]byte("Exif\x00\x00"), tiff...)
+					header := []byte{0xFF, 0xE1, 0, 0}
+					binary.BigEndian.PutUint16(header[2:], uint16(len(exif)+2))
+					b.write(append(header, exif...))
+				}
+			case bytes.HasPrefix(payload, xmpJPEGHeader) || bytes.HasPrefix(payload, xmpExtHeader):
+				result.add(MetadataXMP)
+			default:
+				b.copy(pos, 2+length)
+			}
+		case 0xED:
+			result.add(MetadataIPTC)
+		default:
+			b.copy(pos, 2+length)
+		}
+		pos += 2 + length
+	}
+
+	return result, nil
+}
+
+// stripPNG 移除PNG的eXIf及文本块(tEXt/zTXt/iTXt，含XMP)
+func stripPNG(b *bodyBuilder, size int64) (*StripResult, error) {
+	invalid := fmt.Errorf("无效的PNG数据")
+	result := &StripResult{Orientation: 1}
+	b.write(pngSignature)
+
+	pos := int64(len(pngSignature))
+	for pos < size {
+		if pos+12 > size {
+			return nil, invalid
+		}
+		header, err := b.read(pos, 8)
+		if err != nil {
+			return nil, invalid
+		}
+		length := int64(binary.BigEndian.Uint32(header))
+		end := pos + 12 + length
+		if end > size {
+			return nil, invalid
+		}
+
+		switch chunkType := string(header[4:8]); chunkType {
+		case "eXIf":
+			chunkData, err := b.read(pos+8, length)
+			if err != nil {
+				return nil, invalid
+			}
+			if tiff := result.addExif(chunkData); tiff != nil {
+				var chunk bytes.Buffer
+				writePNGChunk(&chunk, "eXIf", tiff)
+				b.write(chunk.Bytes())
+			}
+		case "tEXt", "zTXt", "iTXt":
+			// 关键字不超过79字节，只读取开头判断类型
+			keyword, err := b.read(pos+8, min(length, 80))
+			if err != nil {
+				return nil, invalid
+			}
+			result.add(pngTextKind(keyword))
+		default:
+			b.copy(pos, end-pos)
+		}
+		pos = end
+	}
+
+	return result, nil
+}
+
+// pngTextKind 根据文本块关键字判断元数据类型
+func pngTextKind(chunkData []byte) string {
+	keyword := chunkData
+	if i := bytes.IndexByte(chunkData, 0); i >= 0 {
+		keyword = chunkData[:i]
+	}
+
+	k := strings.ToLower(string(keyword))
+	switch {
+	case k == "xml:com.adobe.xmp" || strings.Contains(k, "xmp"):
+		return MetadataXMP
+	case strings.Contains(k, "iptc"):
+		return MetadataIPTC
+	case strings.Contains(k, "exif"):
+		return MetadataEXIF
+	}
+	return MetadataText
+}
+
+// writePNGChunk 写入PNG块（含CRC）
+func writePNGChunk(out *bytes.Buffer, chunkType string, chunkData []byte) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(chunkData)))
+	copy(header[4:], chunkType)
+	out.Write(header[:])
+	out.Write(chunkData)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(chunkData)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	out.Write(sum[:])
+}
+
+// WebP VP8X 扩展标志位
+const (
+	webpFlagXMP  = 0x04
+	webpFlagEXIF = 0x08
+)
+
+// stripWebP 移除WebP的EXIF和XMP块，并更新VP8X标志位
+func stripWebP(b *bodyBuilder, size int64) (*StripResult, error) {
+	invalid := fmt.Errorf("无效的WebP数据")
+	result := &StripResult{Orientation: 1}
+	// RIFF头中的大小在处理完成后更新
+	riff, err := b.read(0, 12)
+	if err != nil {
+		return nil, invalid
+	}
+	b.write(riff)
+
+	var vp8x []byte
+	pos := int64(12)
+	for pos < size {
+		if pos+8 > size {
+			return nil, invalid
+		}
+		header, err := b.read(pos, 8)
+		if err != nil {
+			return nil, invalid
+		}
+		fourCC := string(header[:4])
+		chunkSize := int64(binary.LittleEndian.Uint32(header[4:]))
+		end := pos + 8 + chunkSize + chunkSize%2
+		if end > size {
+			// 部分编码器省略了最后一个块的填充字节
+			if pos+8+chunkSize > size {
+				return nil, invalid
+			}
+			end = size
+		}
+
+		switch {
+		case fourCC == "EXIF":
+			chunkData, err := b.read(pos+8, chunkSize)
+			if err != nil {
+				return nil, invalid
+			}
+			tiff := bytes.TrimPrefix(chunkData, []byte("Exif\x00\x00"))
+			if exif := result.addExif(tiff); exif != nil {
+				var chunk bytes.Buffer
+				writeWebPChunk(&chunk, "EXIF", exif)
+				b.write(chunk.Bytes())
+			}
+		case fourCC == "XMP ":
+			result.add(MetadataXMP)
+		case fourCC == "VP8X" && chunkSize > 0:
+			// 标志位在处理完成后更新
+			if vp8x, err = b.read(pos, end-pos); err != nil {
+				return nil, invalid
+			}
+			b.write(vp8x)
+		default:
+			b.copy(pos, end-pos)
+		}
+		pos = end
+	}
+
+	if vp8x != nil {
+		if result.Orientation == 1 {
+			vp8x[8] &^= webpFlagEXIF
+		}
+		vp8x[8] &^= webpFlagXMP
+	}
+	binary.LittleEndian.PutUint32(riff[4:], uint32(b.size()-8))
+
+	return result, nil
+}
+
+// writeWebPChunk 写入RIFF块（含填充字节）
+func writeWebPChunk(out *bytes.Buffer, fourCC string, chunkData []byte) {
+	var header [8]byte
+	copy(header[:4], fourCC)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(chunkData)))
+	out.Write(header[:])
+	out.Write(chunkData)
+	if len(chunkData)%2 == 1 {
+		out.WriteByte(0)
+	}
+}
@@ -0,0 +1,181 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+)
+
+const (
+	// exifOrientationTag EXIF方向标签
+	exifOrientationTag = 0x0112
+	// exifGPSInfoTag EXIF GPS子IFD指针标签
+	exifGPSInfoTag = 0x8825
+)
+
+// ReadOrientation 读取JPEG或PNG(eXIf块)中的EXIF方向 (1-8)，不存在或无法解析时返回1
+func ReadOrientation(data []byte) int {
+	var tiff []byte
+	if app1 := findExifSegment(data); app1 != nil {
+		tiff = app1[6:]
+	} else if chunk := findPNGExifChunk(data); chunk != nil {
+		tiff = chunk
+	} else {
+		return 1
+	}
+	orientation, _ := parseExif(tiff)
+	return orientation
+}
+
+// parseExif 解析TIFF格式的EXIF数据，返回方向和是否包含GPS信息
+func parseExif(tiff []byte) (orientation int, hasGPS bool) {
+	orientation = 1
+
+	// TIFF头: 字节序(II/MM) + 0x002A + IFD0偏移
+	if len(tiff) < 8 {
+		return
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) {
+		return
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return
+		}
+		switch order.Uint16(tiff[entry:]) {
+		case exifOrientationTag:
+			if value := int(order.Uint16(tiff[entry+8:])); value >= 1 && value <= 8 {
+				orientation = value
+			}
+		case exifGPSInfoTag:
+			// GPS子IFD存在且至少有一个条目
+			gpsOffset := int(order.Uint32(tiff[entry+8:]))
+			if gpsOffset+2 <= len(tiff) && order.Uint16(tiff[gpsOffset:]) > 0 {
+				hasGPS = true
+			}
+		}
+	}
+	return
+}
+
+// buildOrientationExif 生成仅包含方向标签的最小TIFF格式EXIF数据
+func buildOrientationExif(orientation int) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	copy(tiff, "II")
+	binary.LittleEndian.PutUint16(tiff[2:], 0x2A)
+	binary.LittleEndian.PutUint32(tiff[4:], 8)
+	binary.LittleEndian.PutUint16(tiff[8:], 1)
+	binary.LittleEndian.PutUint16(tiff[10:], exifOrientationTag)
+	binary.LittleEndian.PutUint16(tiff[12:], 3) // SHORT
+	binary.LittleEndian.PutUint32(tiff[14:], 1)
+	binary.LittleEndian.PutUint16(tiff[18:], uint16(orientation))
+	return tiff
+}
+
+// findExifSegment 查找JPEG的EXIF APP1段内容（含 "Exif\0\0" 头）
+func findExifSegment(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil
+		}
+		marker := data[pos+1]
+		// SOS之后为图像数据，不再有元数据段
+		if marker == 0xDA || marker == 0xD9 {
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && len(segment) >= 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return segment
+		}
+		pos += 2 + length
+	}
+	return nil
+}
+
+// findPNGExifChunk 查找PNG的eXIf块内容（TIFF格式）
+func findPNGExifChunk(data []byte) []byte {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil
+	}
+
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunkType := string(data[pos+4 : pos+8])
+		if pos+12+length > len(data) || chunkType == "IEND" {
+			return nil
+		}
+		if chunkType == "eXIf" {
+			return data[pos+8 : pos+8+length]
+		}
+		pos += 12 + length
+	}
+	return nil
+}
+
+// ApplyOrientation 按EXIF方向旋转/翻转图片，使其以正确方向显示
+func ApplyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	w, h := bounds.Dx(), bounds.Dy()
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // 水平翻转
+				dx, dy = w-1-x, y
+			case 3: // 旋转180度
+				dx, dy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				dx, dy = x, h-1-y
+			case 5: // 沿左上-右下对角线翻转
+				dx, dy = y, x
+			case 6: // 顺时针旋转90度
+				dx, dy = h-1-y, x
+			case 7: // 沿右上-左下对角线翻转
+				dx, dy = h-1-y, w-1-x
+			case 8: // 逆时针旋转90度
+				dx, dy = y, w-1-x
+			}
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
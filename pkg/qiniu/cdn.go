@@ -0,0 +1,133 @@
+package qiniu
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qiniu/go-sdk/v7/cdn"
+)
+
+const (
+	// maxRefreshURLs 单次刷新/预取URL数量上限
+	maxRefreshURLs = 100
+	// maxRefreshDirs 单次刷新目录数量上限
+	maxRefreshDirs = 10
+)
+
+// CDNManager CDN缓存管理器
+type CDNManager struct {
+	manager *cdn.CdnManager
+	client  *Client
+}
+
+// RefreshResult CDN刷新/预取结果
+type RefreshResult struct {
+	RequestID   string
+	URLs        []string
+	Dirs        []string
+	InvalidURLs []string
+	InvalidDirs []string
+
+	// 每日配额及剩余次数
+	URLQuotaDay   int
+	URLSurplusDay int
+	DirQuotaDay   int
+	DirSurplusDay int
+}
+
+// CDN 获取CDN缓存管理器
+func (c *Client) CDN() *CDNManager {
+	return &CDNManager{
+		manager: cdn.NewCdnManager(c.mac()),
+		client:  c,
+	}
+}
+
+// ResolveURL 将存储key转换为完整的访问URL，已是URL时原样返回
+func (m *CDNManager) ResolveURL(target string) string {
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return target
+	}
+	return m.client.generateFileURL(strings.TrimPrefix(target, "/"))
+}
+
+// Refresh 刷新文件和目录缓存，参数可以是存储key或完整URL
+func (m *CDNManager) Refresh(targets []string, dirs []string) (*RefreshResult, error) {
+	if len(targets) == 0 && len(dirs) == 0 {
+		return nil, fmt.Errorf("未指定需要刷新的文件或目录")
+	}
+	if len(targets) > maxRefreshURLs {
+		return nil, fmt.Errorf("单次最多刷新%d个文件", maxRefreshURLs)
+	}
+	if len(dirs) > maxRefreshDirs {
+		return nil, fmt.Errorf("单次最多刷新%d个目录", maxRefreshDirs)
+	}
+
+	urls := m.resolveURLs(targets)
+	dirURLs := m.resolveURLs(dirs)
+	for i, dir := range dirURLs {
+		// 目录刷新要求URL以 / 结尾
+		if !strings.HasSuffix(dir, "/") {
+			dirURLs[i] = dir + "/"
+		}
+	}
+
+	resp, err := m.manager.RefreshUrlsAndDirs(urls, dirURLs)
+	if err != nil {
+		return nil, fmt.Errorf("刷新CDN缓存失败: %v", err)
+	}
+	if resp.Code != 200 {
+		return nil, fmt.Errorf("刷新CDN缓存失败: %d %s", resp.Code, resp.Error)
+	}
+
+	return &RefreshResult{
+		RequestID:     resp.RequestID,
+		URLs:          urls,
+		Dirs:          dirURLs,
+		InvalidURLs:   resp.InvalidUrls,
+		InvalidDirs:   resp.InvalidDirs,
+		URLQuotaDay:   resp.URLQuotaDay,
+		URLSurplusDay: resp.URLSurplusDay,
+		DirQuotaDay:   resp.DirQuotaDay,
+		DirSurplusDay: resp.DirSurplusDay,
+	}, nil
+}
+
+// Prefetch 预取文件到CDN节点，参数可以是存储key或完整URL
+func (m *CDNManager) Prefetch(targets []string) (*RefreshResult, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("未指定需要预取的文件")
+	}
+	if len(targets) > maxRefreshURLs {
+		return nil, fmt.Errorf("单次最多预取%d个文件", maxRefreshURLs)
+	}
+
+	urls := m.resolveURLs(targets)
+	resp, err := m.manager.PrefetchUrls(urls)
+	if err != nil {
+		return nil, fmt.Errorf("预取CDN缓存失败: %v", err)
+	}
+	if resp.Code != 200 {
+		return nil, fmt.Errorf("预取CDN缓存失败: %d %s", resp.Code, resp.Error)
+	}
+
+	return &RefreshResult{
+		RequestID:     resp.RequestID,
+		URLs:          urls,
+		InvalidURLs:   resp.InvalidUrls,
+		URLQuotaDay:   resp.QuotaDay,
+		URLSurplusDay: resp.SurplusDay,
+	}, nil
+}
+
+// resolveURLs 批量转换为访问URL
+func (m *CDNManager) resolveURLs(targets []string) []string {
+	if len(targets) == 0 {
+		return nil
+	}
+	urls := make([]string, 0, len(targets))
+	for _, target := range targets {
+		urls = append(urls, m.ResolveURL(target))
+	}
+	return urls
+}
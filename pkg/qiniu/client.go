@@ -1,8 +1,12 @@
 package qiniu
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,6 +14,7 @@ import (
 
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
 	"github.com/qiniu/go-sdk/v7/storage"
+	"qiniu-uploader/pkg/imaging"
 )
 
 // Client 七牛云客户端
@@ -25,6 +30,16 @@ type Config struct {
 	SecretKey string
 	Bucket    string
 	Domain    string
+
+	// KeyPrefix/KeyTemplate 自动生成存储key的前缀和模板，见 RenderKey
+	KeyPrefix   string
+	KeyTemplate string
+
+	// StyleSeparator 图片样式分隔符，默认 "-"
+	StyleSeparator string
+
+	// StripMetadata 上传前移除EXIF、XMP、IPTC等元数据
+	StripMetadata bool
 }
 
 // UploadResult 上传结果
@@ -35,8 +50,50 @@ type UploadResult struct {
 	FileSize int64
 	Key      string
 	Hash     string
+	MimeType string
+
+	// Width/Height 图片显示尺寸，无法识别时为0
+	Width  int
+	Height int
+
+	// OriginalSize 本地原始文件大小，Processed 为true表示上传的是预处理后的内容
+	OriginalSize int64
+	Processed    bool
+
+	// MetadataRemoved 被移除的元数据类型，GPSRemoved 表示移除了GPS位置信息
+	MetadataRemoved []string
+	GPSRemoved      bool
+
+	// Format 上传的图片格式，与 OriginalFormat 不同时表示进行了格式转换；
+	// ConvertSkipped 表示未进行格式转换，已保留原格式，ConvertSkipReason 为原因
+	Format            string
+	OriginalFormat    string
+	ConvertSkipped    bool
+	ConvertSkipReason string
+
+	// Overwritten 为true表示覆盖了云端已存在的同名文件
+	Overwritten bool
 }
 
+// UploadOptions 上传选项
+type UploadOptions struct {
+	// Key 指定存储key，为空时自动生成；指定时允许覆盖同名文件
+	Key string
+
+	// Preprocess 上传前的图片缩放和压缩选项
+	Preprocess imaging.Options
+
+	// KeepMetadata 保留元数据，忽略配置中的 StripMetadata
+	KeepMetadata bool
+}
+
+const (
+	// maxUploadSize 上传文件大小上限
+	maxUploadSize = 10 * 1024 * 1024
+	// maxPreprocessSize 可预处理的原始文件大小上限
+	maxPreprocessSize = 50 * 1024 * 1024
+)
+
 // NewClient 创建新的七牛云客户端
 func NewClient(cfg *Config) *Client {
 	mac := qbox.NewMac(cfg.AccessKey, cfg.SecretKey)
@@ -56,6 +113,15 @@ func NewClient(cfg *Config) *Client {
 
 // UploadFile 上传文件到七牛云
 func (c *Client) UploadFile(filePath string) (*UploadResult, error) {
+	return c.UploadFileWithOptions(filePath, nil)
+}
+
+// UploadFileWithOptions 按指定选项上传文件到七牛云
+func (c *Client) UploadFileWithOptions(filePath string, opts *UploadOptions) (*UploadResult, error) {
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+
 	// 检查文件是否存在
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
@@ -65,14 +131,6 @@ func (c *Client) UploadFile(filePath string) (*UploadResult, error) {
 		}, err
 	}
 
-	// 验证文件大小（最大10MB）
-	if fileInfo.Size() > 10*1024*1024 {
-		return &UploadResult{
-			Success: false,
-			Message: "文件大小超过10MB限制",
-		}, fmt.Errorf("文件大小超过限制")
-	}
-
 	// 验证文件类型
 	if !c.isImageFile(filePath) {
 		return &UploadResult{
@@ -81,28 +139,69 @@ func (c *Client) UploadFile(filePath string) (*UploadResult, error) {
 		}, fmt.Errorf("不支持的文件类型")
 	}
 
-	// 打开文件
-	file, err := os.Open(filePath)
+	// 读取文件内容，启用预处理时先缩放/重新压缩
+	body, processed, err := c.prepareBody(filePath, fileInfo, opts)
 	if err != nil {
 		return &UploadResult{
 			Success: false,
-			Message: fmt.Sprintf("打开文件失败: %v", err),
+			Message: err.Error(),
 		}, err
 	}
-	defer file.Close()
+	if closer, ok := body.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	// 验证文件大小（最大10MB）
+	size := fileInfo.Size()
+	if processed != nil {
+		size = processed.FinalSize
+	}
+	if size > maxUploadSize {
+		return &UploadResult{
+			Success: false,
+			Message: "文件大小超过10MB限制",
+		}, fmt.Errorf("文件大小超过限制")
+	}
+
+	// 格式转换后扩展名和MIME类型随之变化
+	filename := filepath.Base(filePath)
+	key := opts.Key
+	var putExtra *storage.PutExtra
+	if processed != nil && processed.Format != processed.OriginalFormat {
+		filename = replaceExt(filename, imaging.FormatExtension(processed.Format))
+		if key != "" && strings.EqualFold(filepath.Ext(key), filepath.Ext(filePath)) {
+			key = replaceExt(key, imaging.FormatExtension(processed.Format))
+		}
+		putExtra = &storage.PutExtra{MimeType: imaging.FormatMIMEType(processed.Format)}
+	}
 
 	// 生成存储key
-	key := c.generateFileKey(filepath.Base(filePath))
+	scope := c.config.Bucket
+	overwritten := false
+	if key == "" {
+		key = c.generateFileKey(filename)
+	} else {
+		// 指定key时允许覆盖，并记录是否替换了已有文件
+		exists, err := c.Exists(key)
+		if err != nil {
+			return &UploadResult{
+				Success: false,
+				Message: fmt.Sprintf("查询文件状态失败: %v", err),
+			}, err
+		}
+		overwritten = exists
+		scope = fmt.Sprintf("%s:%s", c.config.Bucket, key)
+	}
 
 	// 获取上传凭证
 	putPolicy := storage.PutPolicy{
-		Scope: c.config.Bucket,
+		Scope: scope,
 	}
 	upToken := putPolicy.UploadToken(c.mac())
 
 	// 上传文件
 	ret := storage.PutRet{}
-	err = c.formUploader.Put(context.Background(), &ret, upToken, key, file, fileInfo.Size(), nil)
+	err = c.formUploader.Put(context.Background(), &ret, upToken, key, body, size, putExtra)
 	if err != nil {
 		return &UploadResult{
 			Success: false,
@@ -113,16 +212,115 @@ func (c *Client) UploadFile(filePath string) (*UploadResult, error) {
 	// 生成访问URL
 	fileURL := c.generateFileURL(ret.Key)
 
-	return &UploadResult{
+	result := &UploadResult{
 		Success:  true,
 		Message:  "上传成功",
 		FileURL:  fileURL,
-		FileSize: fileInfo.Size(),
+		FileSize: size,
 		Key:      ret.Key,
 		Hash:     ret.Hash,
+
+		OriginalSize: fileInfo.Size(),
+		Overwritten:  overwritten,
+	}
+	if processed != nil {
+		result.Processed = processed.Processed
+		result.MetadataRemoved = processed.MetadataRemoved
+		result.GPSRemoved = processed.GPSRemoved
+		result.Format = processed.Format
+		result.OriginalFormat = processed.OriginalFormat
+		result.ConvertSkipped = processed.ConvertSkipped
+		result.ConvertSkipReason = processed.ConvertSkipReason
+		result.Width = processed.Width
+		result.Height = processed.Height
+	} else if info, err := imaging.ReadInfo(filePath); err == nil {
+		result.Format = info.Format
+		result.OriginalFormat = info.Format
+		result.Width = info.Width
+		result.Height = info.Height
+	}
+	result.MimeType = mime.TypeByExtension(filepath.Ext(key))
+	if result.Format != "" {
+		result.MimeType = imaging.FormatMIMEType(result.Format)
+	}
+	return result, nil
+}
+
+// prepareBody 打开待上传文件，启用预处理时返回处理后的内容及处理结果
+func (c *Client) prepareBody(filePath string, fileInfo os.FileInfo, opts *UploadOptions) (io.Reader, *imaging.Result, error) {
+	preprocess := opts.Preprocess
+	preprocess.StripMetadata = c.config.StripMetadata && !opts.KeepMetadata
+
+	if !preprocess.Enabled() {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("打开文件失败: %v", err)
+		}
+		return file, nil, nil
+	}
+
+	if fileInfo.Size() > maxPreprocessSize {
+		return nil, nil, fmt.Errorf("文件过大，无法预处理")
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	result, err := imaging.Process(data, preprocess)
+	if err != nil {
+		return nil, nil, fmt.Errorf("图片预处理失败: %v", err)
+	}
+	return bytes.NewReader(result.Data), result, nil
+}
+
+// Exists 检查云端是否已存在指定key的文件
+func (c *Client) Exists(key string) (bool, error) {
+	_, err := c.bucketManager.Stat(c.config.Bucket, key)
+	if err == nil {
+		return true, nil
+	}
+	if errInfo, ok := err.(*storage.ErrorInfo); ok && errInfo.Code == 612 {
+		return false, nil
+	}
+	return false, err
+}
+
+// ErrNotFound 云端文件不存在
+var ErrNotFound = errors.New("云端文件不存在")
+
+// Stat 获取云端文件信息，文件不存在时返回 ErrNotFound
+func (c *Client) Stat(key string) (*FileInfo, error) {
+	info, err := c.bucketManager.Stat(c.config.Bucket, key)
+	if err != nil {
+		if errInfo, ok := err.(*storage.ErrorInfo); ok && errInfo.Code == 612 {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("获取文件信息失败: %v", err)
+	}
+
+	return &FileInfo{
+		Key:      key,
+		URL:      c.generateFileURL(key),
+		FileSize: info.Fsize,
+		MimeType: info.MimeType,
+		Hash:     info.Hash,
+		Uploaded: time.Unix(info.PutTime/10000000, 0),
 	}, nil
 }
 
+// Delete 删除云端文件，文件不存在时返回 ErrNotFound
+func (c *Client) Delete(key string) error {
+	err := c.bucketManager.Delete(c.config.Bucket, key)
+	if err != nil {
+		if errInfo, ok := err.(*storage.ErrorInfo); ok && errInfo.Code == 612 {
+			return ErrNotFound
+		}
+		return fmt.Errorf("删除文件失败: %v", err)
+	}
+	return nil
+}
+
 // ListFiles 获取文件列表
 func (c *Client) ListFiles(prefix string, limit int) ([]FileInfo, error) {
 	entries, _, _, hasNext, err := c.bucketManager.ListFiles(
@@ -144,6 +342,7 @@ func (c *Client) ListFiles(prefix string, limit int) ([]FileInfo, error) {
 				URL:      c.generateFileURL(entry.Key),
 				FileSize: entry.Fsize,
 				MimeType: entry.MimeType,
+				Hash:     entry.Hash,
 				Uploaded: time.Unix(entry.PutTime/10000000, 0),
 			}
 			files = append(files, file)
@@ -162,14 +361,18 @@ type FileInfo struct {
 	URL      string
 	FileSize int64
 	MimeType string
+	Hash     string
 	Uploaded time.Time
 }
 
 // generateFileKey 生成文件存储key
 func (c *Client) generateFileKey(filename string) string {
-	ext := filepath.Ext(filename)
-	timestamp := time.Now().UnixNano()
-	return fmt.Sprintf("images/%d%s", timestamp, ext)
+	return RenderKey(c.config.KeyTemplate, c.config.KeyPrefix, filename, time.Now())
+}
+
+// replaceExt 替换文件名扩展名
+func replaceExt(name, ext string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ext
 }
 
 // generateFileURL 生成文件访问URL
@@ -186,7 +389,7 @@ func (c *Client) generateFileURL(key string) string {
 // isImageFile 检查是否为图片文件
 func (c *Client) isImageFile(filename string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
-	imageExts := []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp"}
+	imageExts := []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp", ".avif"}
 
 	for _, imageExt := range imageExts {
 		if ext == imageExt {
@@ -199,4 +402,4 @@ func (c *Client) isImageFile(filename string) bool {
 // mac 获取七牛云认证对象
 func (c *Client) mac() *qbox.Mac {
 	return qbox.NewMac(c.config.AccessKey, c.config.SecretKey)
-}
\ No newline at end of file
+}
@@ -0,0 +1,359 @@
+package qiniu
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 图片处理锚点位置
+const (
+	GravityNorthWest = "NorthWest"
+	GravityNorth     = "North"
+	GravityNorthEast = "NorthEast"
+	GravityWest      = "West"
+	GravityCenter    = "Center"
+	GravityEast      = "East"
+	GravitySouthWest = "SouthWest"
+	GravitySouth     = "South"
+	GravitySouthEast = "SouthEast"
+)
+
+// imageView2 缩略模式
+const (
+	// ViewModeCrop 限定缩略图宽高最小值，居中裁剪
+	ViewModeCrop = 1
+	// ViewModeFit 限定缩略图宽高最大值，等比缩放
+	ViewModeFit = 2
+)
+
+// ImageView2 imageView2 基本缩略处理参数
+type ImageView2 struct {
+	Mode    int
+	Width   int
+	Height  int
+	Format  string
+	Quality int
+}
+
+// String 生成 imageView2 处理指令
+func (v ImageView2) String() string {
+	parts := []string{"imageView2", strconv.Itoa(v.Mode)}
+	if v.Width > 0 {
+		parts = append(parts, "w", strconv.Itoa(v.Width))
+	}
+	if v.Height > 0 {
+		parts = append(parts, "h", strconv.Itoa(v.Height))
+	}
+	if v.Format != "" {
+		parts = append(parts, "format", v.Format)
+	}
+	if v.Quality > 0 {
+		parts = append(parts, "q", strconv.Itoa(v.Quality))
+	}
+	return strings.Join(parts, "/")
+}
+
+// ImageMogr2 imageMogr2 高级处理参数
+type ImageMogr2 struct {
+	AutoOrient bool
+	Thumbnail  string // 缩放规格，如 "300x300"、"1920x"
+	Gravity    string
+	Crop       string // 裁剪规格，如 "300x300"
+	Rotate     int
+	Format     string
+	Quality    int
+	BlurRadius int
+	BlurSigma  int
+	Strip      bool
+}
+
+// String 生成 imageMogr2 处理指令
+func (m ImageMogr2) String() string {
+	parts := []string{"imageMogr2"}
+	if m.AutoOrient {
+		parts = append(parts, "auto-orient")
+	}
+	if m.Thumbnail != "" {
+		parts = append(parts, "thumbnail", m.Thumbnail)
+	}
+	if m.Crop != "" {
+		if m.Gravity != "" {
+			parts = append(parts, "gravity", m.Gravity)
+		}
+		parts = append(parts, "crop", m.Crop)
+	}
+	if m.Rotate != 0 {
+		parts = append(parts, "rotate", strconv.Itoa(m.Rotate))
+	}
+	if m.BlurRadius > 0 {
+		parts = append(parts, "blur", fmt.Sprintf("%dx%d", m.BlurRadius, m.BlurSigma))
+	}
+	if m.Format != "" {
+		parts = append(parts, "format", m.Format)
+	}
+	if m.Quality > 0 {
+		parts = append(parts, "quality", strconv.Itoa(m.Quality))
+	}
+	if m.Strip {
+		parts = append(parts, "strip")
+	}
+	return strings.Join(parts, "/")
+}
+
+// Watermark 文字或图片水印参数，Text 与 Image 二选一
+type Watermark struct {
+	Text     string
+	Font     string
+	FontSize int
+	Fill     string // 文字颜色，如 "#FFFFFF"
+	Image    string // 水印图片URL
+	Dissolve int    // 透明度 1-100
+	Gravity  string
+	Dx       int
+	Dy       int
+}
+
+// String 生成 watermark 处理指令
+func (w Watermark) String() string {
+	var parts []string
+	if w.Image != "" {
+		parts = []string{"watermark", "1", "image", urlSafeBase64(w.Image)}
+	} else {
+		parts = []string{"watermark", "2", "text", urlSafeBase64(w.Text)}
+		if w.Font != "" {
+			parts = append(parts, "font", urlSafeBase64(w.Font))
+		}
+		if w.FontSize > 0 {
+			parts = append(parts, "fontsize", strconv.Itoa(w.FontSize))
+		}
+		if w.Fill != "" {
+			parts = append(parts, "fill", urlSafeBase64(w.Fill))
+		}
+	}
+	if w.Dissolve > 0 {
+		parts = append(parts, "dissolve", strconv.Itoa(w.Dissolve))
+	}
+	if w.Gravity != "" {
+		parts = append(parts, "gravity", w.Gravity)
+	}
+	if w.Dx != 0 {
+		parts = append(parts, "dx", strconv.Itoa(w.Dx))
+	}
+	if w.Dy != 0 {
+		parts = append(parts, "dy", strconv.Itoa(w.Dy))
+	}
+	return strings.Join(parts, "/")
+}
+
+// ImageURLBuilder 图片处理URL构建器
+type ImageURLBuilder struct {
+	baseURL    string
+	view       *ImageView2
+	mogr       *ImageMogr2
+	watermarks []Watermark
+	format     string
+	quality    int
+	style      string
+	separator  string
+}
+
+// NewImageURLBuilder 基于图片访问URL创建构建器
+func NewImageURLBuilder(baseURL string) *ImageURLBuilder {
+	return &ImageURLBuilder{
+		baseURL:   baseURL,
+		separator: "-",
+	}
+}
+
+// ImageURL 为指定key创建图片处理URL构建器
+func (c *Client) ImageURL(key string) *ImageURLBuilder {
+	b := NewImageURLBuilder(c.generateFileURL(key))
+	if c.config.StyleSeparator != "" {
+		b.separator = c.config.StyleSeparator
+	}
+	return b
+}
+
+// Thumbnail 使用 imageView2 生成缩略图
+func (b *ImageURLBuilder) Thumbnail(mode, width, height int) *ImageURLBuilder {
+	b.view = &ImageView2{Mode: mode, Width: width, Height: height}
+	return b
+}
+
+// Resize 等比缩放，宽或高为0时按另一边缩放
+func (b *ImageURLBuilder) Resize(width, height int) *ImageURLBuilder {
+	b.mogrOps().Thumbnail = FormatImageSize(width, height)
+	return b
+}
+
+// Crop 按锚点裁剪指定大小
+func (b *ImageURLBuilder) Crop(width, height int, gravity string) *ImageURLBuilder {
+	m := b.mogrOps()
+	m.Crop = FormatImageSize(width, height)
+	m.Gravity = gravity
+	return b
+}
+
+// Format 转换输出格式，如 webp、jpg、png
+func (b *ImageURLBuilder) Format(format string) *ImageURLBuilder {
+	b.format = strings.ToLower(format)
+	return b
+}
+
+// Quality 设置输出质量 1-100
+func (b *ImageURLBuilder) Quality(quality int) *ImageURLBuilder {
+	b.quality = quality
+	return b
+}
+
+// Strip 去除图片元信息
+func (b *ImageURLBuilder) Strip() *ImageURLBuilder {
+	b.mogrOps().Strip = true
+	return b
+}
+
+// AutoOrient 根据EXIF方向自动旋正
+func (b *ImageURLBuilder) AutoOrient() *ImageURLBuilder {
+	b.mogrOps().AutoOrient = true
+	return b
+}
+
+// Rotate 顺时针旋转指定角度
+func (b *ImageURLBuilder) Rotate(degree int) *ImageURLBuilder {
+	b.mogrOps().Rotate = degree
+	return b
+}
+
+// Blur 高斯模糊
+func (b *ImageURLBuilder) Blur(radius, sigma int) *ImageURLBuilder {
+	m := b.mogrOps()
+	m.BlurRadius = radius
+	m.BlurSigma = sigma
+	return b
+}
+
+// TextWatermark 添加文字水印
+func (b *ImageURLBuilder) TextWatermark(text string, opts Watermark) *ImageURLBuilder {
+	opts.Text = text
+	opts.Image = ""
+	b.watermarks = append(b.watermarks, opts)
+	return b
+}
+
+// ImageWatermark 添加图片水印
+func (b *ImageURLBuilder) ImageWatermark(imageURL string, opts Watermark) *ImageURLBuilder {
+	opts.Image = imageURL
+	b.watermarks = append(b.watermarks, opts)
+	return b
+}
+
+// Style 使用控制台中定义的命名样式，设置后忽略其他处理参数
+func (b *ImageURLBuilder) Style(name string) *ImageURLBuilder {
+	b.style = name
+	return b
+}
+
+// Separator 设置样式分隔符，支持 "-" 和 "!"
+func (b *ImageURLBuilder) Separator(sep string) *ImageURLBuilder {
+	b.separator = sep
+	return b
+}
+
+// Fop 生成处理指令（多个指令以管道符连接）
+func (b *ImageURLBuilder) Fop() string {
+	var fops []string
+
+	view := b.view
+	mogr := b.mogr
+	if b.format != "" || b.quality > 0 {
+		// 格式和质量优先放入 imageMogr2，仅有 imageView2 时放入 imageView2
+		if mogr == nil && view != nil {
+			v := *view
+			v.Format = b.format
+			v.Quality = b.quality
+			view = &v
+		} else {
+			m := ImageMogr2{}
+			if mogr != nil {
+				m = *mogr
+			}
+			m.Format = b.format
+			m.Quality = b.quality
+			mogr = &m
+		}
+	}
+
+	if view != nil {
+		fops = append(fops, view.String())
+	}
+	if mogr != nil {
+		fops = append(fops, mogr.String())
+	}
+	for _, w := range b.watermarks {
+		fops = append(fops, w.String())
+	}
+	return strings.Join(fops, "|")
+}
+
+// String 生成最终的访问URL
+func (b *ImageURLBuilder) String() string {
+	if b.style != "" {
+		return b.baseURL + b.separator + b.style
+	}
+
+	fop := b.Fop()
+	if fop == "" {
+		return b.baseURL
+	}
+	if strings.Contains(b.baseURL, "?") {
+		return b.baseURL + "&" + fop
+	}
+	return b.baseURL + "?" + fop
+}
+
+// mogrOps 获取或创建 imageMogr2 参数
+func (b *ImageURLBuilder) mogrOps() *ImageMogr2 {
+	if b.mogr == nil {
+		b.mogr = &ImageMogr2{}
+	}
+	return b.mogr
+}
+
+// ParseImageSize 解析尺寸字符串，支持 "300x300"、"1920x"、"x1080"
+func ParseImageSize(size string) (width, height int, err error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(size)), "x")
+	if len(parts) != 2 || (parts[0] == "" && parts[1] == "") {
+		return 0, 0, fmt.Errorf("无效的尺寸格式: %s（示例: 300x300、1920x、x1080）", size)
+	}
+
+	if parts[0] != "" {
+		if width, err = strconv.Atoi(parts[0]); err != nil || width <= 0 {
+			return 0, 0, fmt.Errorf("无效的宽度: %s", parts[0])
+		}
+	}
+	if parts[1] != "" {
+		if height, err = strconv.Atoi(parts[1]); err != nil || height <= 0 {
+			return 0, 0, fmt.Errorf("无效的高度: %s", parts[1])
+		}
+	}
+	return width, height, nil
+}
+
+// FormatImageSize 生成尺寸字符串，宽或高为0时省略
+func FormatImageSize(width, height int) string {
+	var w, h string
+	if width > 0 {
+		w = strconv.Itoa(width)
+	}
+	if height > 0 {
+		h = strconv.Itoa(height)
+	}
+	return w + "x" + h
+}
+
+// urlSafeBase64 URL安全的Base64编码
+func urlSafeBase64(s string) string {
+	return base64.URLEncoding.EncodeToString([]byte(s))
+}
@@ -0,0 +1,115 @@
+package qiniu
+
+import (
+	"testing"
+)
+
+func TestImageURLBuilder(t *testing.T) {
+	const base = "https://cdn.example.com/images/a.jpg"
+
+	tests := []struct {
+		name     string
+		build    func(b *ImageURLBuilder) *ImageURLBuilder
+		expected string
+	}{
+		{
+			name:     "No processing",
+			build:    func(b *ImageURLBuilder) *ImageURLBuilder { return b },
+			expected: base,
+		},
+		{
+			name: "Thumbnail with format",
+			build: func(b *ImageURLBuilder) *ImageURLBuilder {
+				return b.Thumbnail(ViewModeCrop, 300, 300).Format("WebP")
+			},
+			expected: base + "?imageView2/1/w/300/h/300/format/webp",
+		},
+		{
+			name: "Mogr pipeline",
+			build: func(b *ImageURLBuilder) *ImageURLBuilder {
+				return b.Resize(1920, 0).Crop(800, 600, GravityCenter).Rotate(90).Blur(20, 5).Quality(85).Strip()
+			},
+			expected: base + "?imageMogr2/thumbnail/1920x/gravity/Center/crop/800x600/rotate/90/blur/20x5/quality/85/strip",
+		},
+		{
+			name: "Thumbnail and mogr",
+			build: func(b *ImageURLBuilder) *ImageURLBuilder {
+				return b.Thumbnail(ViewModeFit, 0, 200).AutoOrient().Format("png")
+			},
+			expected: base + "?imageView2/2/h/200|imageMogr2/auto-orient/format/png",
+		},
+		{
+			name: "Text watermark",
+			build: func(b *ImageURLBuilder) *ImageURLBuilder {
+				return b.TextWatermark("hello", Watermark{FontSize: 500, Gravity: GravitySouthEast, Dx: 10, Dy: 10})
+			},
+			expected: base + "?watermark/2/text/aGVsbG8=/fontsize/500/gravity/SouthEast/dx/10/dy/10",
+		},
+		{
+			name: "Image watermark",
+			build: func(b *ImageURLBuilder) *ImageURLBuilder {
+				return b.ImageWatermark("https://a.com/logo.png", Watermark{Dissolve: 50})
+			},
+			expected: base + "?watermark/1/image/aHR0cHM6Ly9hLmNvbS9sb2dvLnBuZw==/dissolve/50",
+		},
+		{
+			name: "Named style",
+			build: func(b *ImageURLBuilder) *ImageURLBuilder {
+				return b.Style("thumb").Format("webp")
+			},
+			expected: base + "-thumb",
+		},
+		{
+			name: "Named style with bang separator",
+			build: func(b *ImageURLBuilder) *ImageURLBuilder {
+				return b.Separator("!").Style("thumb")
+			},
+			expected: base + "!thumb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.build(NewImageURLBuilder(base)).String()
+			if result != tt.expected {
+				t.Errorf("got %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseImageSize(t *testing.T) {
+	tests := []struct {
+		input       string
+		width       int
+		height      int
+		shouldError bool
+	}{
+		{"300x300", 300, 300, false},
+		{"1920x", 1920, 0, false},
+		{"x1080", 0, 1080, false},
+		{"800X600", 800, 600, false},
+		{"x", 0, 0, true},
+		{"300", 0, 0, true},
+		{"-1x100", 0, 0, true},
+		{"abcx100", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			w, h, err := ParseImageSize(tt.input)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("ParseImageSize(%q) expected error", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseImageSize(%q) unexpected error: %v", tt.input, err)
+			}
+			if w != tt.width || h != tt.height {
+				t.Errorf("ParseImageSize(%q) = %dx%d, expected %dx%d", tt.input, w, h, tt.width, tt.height)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package qiniu
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 存储key默认规则，生成 images/<纳秒时间戳><扩展名>
+const (
+	DefaultKeyPrefix   = "images/"
+	DefaultKeyTemplate = "{prefix}{timestamp}{ext}"
+)
+
+// KeyPlaceholders key模板支持的占位符
+var KeyPlaceholders = []string{"{prefix}", "{name}", "{ext}", "{timestamp}", "{date}", "{year}", "{month}", "{day}", "{rand}"}
+
+// placeholderPattern 匹配模板中的占位符
+var placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// ValidateKeyTemplate 检查key模板中的占位符是否都受支持
+func ValidateKeyTemplate(template string) error {
+	for _, p := range placeholderPattern.FindAllString(template, -1) {
+		known := false
+		for _, k := range KeyPlaceholders {
+			if p == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("未知的占位符 %s（支持 %s）", p, strings.Join(KeyPlaceholders, " "))
+		}
+	}
+	return nil
+}
+
+// RenderKey 按模板生成存储key，template 为空时使用 DefaultKeyTemplate。
+// 支持的占位符:
+//
+//	{prefix}    key前缀
+//	{name}      本地文件名（不含扩展名）
+//	{ext}       扩展名（含 .）
+//	{timestamp} 纳秒时间戳
+//	{date}      日期，如 2006/01/02
+//	{year} {month} {day}
+//	{rand}      8位随机十六进制字符
+func RenderKey(template, prefix, filename string, now time.Time) string {
+	if template == "" {
+		template = DefaultKeyTemplate
+	}
+
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	replacer := strings.NewReplacer(
+		"{prefix}", prefix,
+		"{name}", strings.TrimSuffix(base, ext),
+		"{ext}", ext,
+		"{timestamp}", strconv.FormatInt(now.UnixNano(), 10),
+		"{date}", now.Format("2006/01/02"),
+		"{year}", now.Format("2006"),
+		"{month}", now.Format("01"),
+		"{day}", now.Format("02"),
+		"{rand}", randomHex(4),
+	)
+	return strings.TrimLeft(replacer.Replace(template), "/")
+}
+
+// randomHex 生成 n 字节的随机十六进制字符串
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
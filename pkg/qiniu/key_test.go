@@ -0,0 +1,52 @@
+package qiniu
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRenderKey(t *testing.T) {
+	now := time.Date(2024, 3, 9, 10, 30, 0, 123, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		prefix   string
+		filename string
+		expected string
+	}{
+		{"Default template", "", "images/", "/tmp/photo.jpg", "images/1709980200000000123.jpg"},
+		{"Date and name", "{prefix}{date}/{name}{ext}", "blog/", "diagram.png", "blog/2024/03/09/diagram.png"},
+		{"Year and month", "{year}-{month}-{day}/{timestamp}{ext}", "", "a.gif", "2024-03-09/1709980200000000123.gif"},
+		{"Empty prefix strips leading slash", "{prefix}/{name}{ext}", "", "a.png", "a.png"},
+		{"Literal text", "static/logo{ext}", "images/", "logo.svg", "static/logo.svg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderKey(tt.template, tt.prefix, tt.filename, now)
+			if got != tt.expected {
+				t.Errorf("RenderKey(%q) = %q, expected %q", tt.template, got, tt.expected)
+			}
+		})
+	}
+
+	got := RenderKey("{prefix}{rand}{ext}", "p/", "a.png", now)
+	if !regexp.MustCompile(`^p/[0-9a-f]{8}\.png$`).MatchString(got) {
+		t.Errorf("RenderKey with {rand} = %q", got)
+	}
+}
+
+func TestValidateKeyTemplate(t *testing.T) {
+	for _, template := range []string{"", "{prefix}{timestamp}{ext}", "static/{year}/{name}-{rand}{ext}"} {
+		if err := ValidateKeyTemplate(template); err != nil {
+			t.Errorf("ValidateKeyTemplate(%q) error: %v", template, err)
+		}
+	}
+	for _, template := range []string{"{prefix}{hash}{ext}", "{Name}"} {
+		if err := ValidateKeyTemplate(template); err == nil {
+			t.Errorf("ValidateKeyTemplate(%q) expected error", template)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package qiniu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+// ErrUnauthorized AccessKey 或 SecretKey 无效
+var ErrUnauthorized = errors.New("AccessKey 或 SecretKey 无效")
+
+// ErrBucketNotFound 当前账号下不存在该存储空间
+var ErrBucketNotFound = errors.New("存储空间不存在或无权访问")
+
+// serverTimeURL 用于读取服务器时间的七牛云接口地址
+const serverTimeURL = "https://uc.qbox.me"
+
+// BucketStatus 存储空间信息
+type BucketStatus struct {
+	// Region 区域ID，如 z0、z2、na0
+	Region string
+	// Private 是否为私有空间
+	Private bool
+}
+
+// CheckBucket 验证密钥并查询存储空间所在区域。密钥无效时返回 ErrUnauthorized，
+// 存储空间不存在时返回 ErrBucketNotFound
+func (c *Client) CheckBucket() (*BucketStatus, error) {
+	input := &storage.BucketV4Input{Limit: 100}
+	for {
+		output, err := c.bucketManager.BucketsV4(input)
+		if err != nil {
+			if isUnauthorized(err) {
+				return nil, ErrUnauthorized
+			}
+			return nil, fmt.Errorf("获取存储空间列表失败: %v", err)
+		}
+		for _, bucket := range output.Buckets {
+			if bucket.Name == c.config.Bucket {
+				return &BucketStatus{Region: bucket.Region, Private: bucket.Private}, nil
+			}
+		}
+		if !output.IsTruncated || output.NextMarker == "" {
+			return nil, ErrBucketNotFound
+		}
+		input.Marker = output.NextMarker
+	}
+}
+
+// BucketDomains 返回存储空间绑定的域名
+func (c *Client) BucketDomains() ([]string, error) {
+	infos, err := c.bucketManager.ListBucketDomains(c.config.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("获取存储空间域名失败: %v", err)
+	}
+	domains := make([]string, 0, len(infos))
+	for _, info := range infos {
+		domains = append(domains, info.Domain)
+	}
+	return domains, nil
+}
+
+// PutBytes 上传内容到指定key，已存在时覆盖
+func (c *Client) PutBytes(key string, data []byte) error {
+	putPolicy := storage.PutPolicy{
+		Scope: fmt.Sprintf("%s:%s", c.config.Bucket, key),
+	}
+	upToken := putPolicy.UploadToken(c.mac())
+
+	ret := storage.PutRet{}
+	err := c.formUploader.Put(context.Background(), &ret, upToken, key, bytes.NewReader(data), int64(len(data)), nil)
+	if err != nil {
+		if isUnauthorized(err) {
+			return ErrUnauthorized
+		}
+		return fmt.Errorf("上传失败: %v", err)
+	}
+	return nil
+}
+
+// FileURL 返回文件的访问URL
+func (c *Client) FileURL(key string) string {
+	return c.generateFileURL(key)
+}
+
+// ServerTime 读取七牛云服务器的当前时间，用于检测本机时钟偏差
+func ServerTime(ctx context.Context) (time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, serverTimeURL, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("连接七牛云失败: %v", err)
+	}
+	resp.Body.Close()
+
+	date := resp.Header.Get("Date")
+	if date == "" {
+		return time.Time{}, fmt.Errorf("服务器响应中没有时间")
+	}
+	return http.ParseTime(date)
+}
+
+// isUnauthorized 判断是否为鉴权失败
+func isUnauthorized(err error) bool {
+	var errInfo *storage.ErrorInfo
+	if errors.As(err, &errInfo) {
+		return errInfo.Code == http.StatusUnauthorized
+	}
+	return strings.Contains(err.Error(), "bad token")
+}
+
+// regionNames 常见区域ID对应的名称
+var regionNames = map[string]string{
+	"z0":             "华东-浙江",
+	"cn-east-2":      "华东-浙江2",
+	"z1":             "华北-河北",
+	"z2":             "华南-广东",
+	"cn-northwest-1": "西北-陕西",
+	"na0":            "北美-洛杉矶",
+	"as0":            "亚太-新加坡",
+	"ap-northeast-1": "亚太-首尔",
+	"ap-southeast-2": "亚太-河内",
+	"ap-southeast-3": "亚太-胡志明",
+}
+
+// RegionName 返回区域的中文名称，未知区域返回ID
+func RegionName(id string) string {
+	if name, ok := regionNames[id]; ok {
+		return fmt.Sprintf("%s (%s)", name, id)
+	}
+	return id
+}